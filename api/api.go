@@ -1,8 +1,13 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,8 +21,9 @@ import (
 	"github.com/stanislavCasciuc/atom-fit/api/response"
 	"github.com/stanislavCasciuc/atom-fit/docs"
 	"github.com/stanislavCasciuc/atom-fit/internal/auth"
-	"github.com/stanislavCasciuc/atom-fit/internal/env"
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/workers"
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
@@ -26,11 +32,17 @@ type userKey string
 const UserCtx userKey = "user"
 
 type Application struct {
-	Config config.Config
-	Log    *zap.SugaredLogger
-	Store  store.Storage
+	Config        config.Config
+	Log           *zap.SugaredLogger
+	Store         store.Storage
+	Mailer        mailer.Mailer
+	Authenticator auth.Authenticator
+	Workers       *workers.Registry
 }
 
+// Run serves mux until the process gets SIGINT or SIGTERM. It then stops
+// accepting connections and waits up to the shutdown timeout for the
+// in-flight requests and the background workers to finish.
 func (a *Application) Run(mux http.Handler) error {
 	// Docs
 	docs.SwaggerInfo.Version = "1.0"
@@ -42,31 +54,50 @@ func (a *Application) Run(mux http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
 	a.Log.Infow("server has started", "addr", a.Config.Addr, "env", a.Config.Env)
 
-	return srv.ListenAndServe()
+	var err error
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		// a second signal kills the process without waiting
+		stop()
+		a.Log.Infow("shutting down", "timeout", a.Config.ShutdownTimeout.String())
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+
+	if err == nil {
+		err = srv.Shutdown(shutdownCtx)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+
+	return errors.Join(err, a.Workers.Stop(shutdownCtx))
 }
 
 func (a *Application) Mount() http.Handler {
 	resp := response.New(a.Log)
-	authenticator := auth.New(a.Config.Auth.Secret, a.Config.Auth.Aud)
-	h := handlers.New(resp, a.Store, a.Config, authenticator)
-	m := customMiddleware.New(a.Store, resp, authenticator)
+	h := handlers.New(resp, a.Store, a.Config, a.Authenticator, a.Mailer, a.Log, a.Workers)
+	m := customMiddleware.New(a.Store, resp, a.Authenticator)
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins: []string{
-			env.EnvString("CORS_ALLOWED_ORIGIN", "http://localhost:3000"),
-			env.EnvString("CORS_ALLOWED_ORIGIN_PROD", "https://atom-fit.vercel.app"),
-			env.EnvString(
-				"CORS_ALLOWED_ORIGIN_PROD_BACKEND",
-				"https://grumpy-batsheva-atomfit-abf2aa42.koyeb.app",
-			),
-		},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedOrigins:   a.Config.CORS.AllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
@@ -81,24 +112,46 @@ func (a *Application) Mount() http.Handler {
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL(docsUrl), // The url pointing to API definition
 	))
+	r.Get("/.well-known/jwks.json", h.JWKSHandler)
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
 			r.Get("/health", h.HealthHandler)
 			r.Route("/auth", func(r chi.Router) {
 				r.Post("/register", h.RegisterUserHandler)
 				r.Post("/login", h.LoginHandler)
+				r.Post("/resend-verification", h.ResendVerificationHandler)
+				r.Post("/forgot-password", h.ForgotPasswordHandler)
+				r.Post("/reset-password", h.ResetPasswordHandler)
+				r.Post("/refresh", h.RefreshHandler)
+				r.With(m.AuthTokenMiddleware).Post("/logout", h.LogoutHandler)
+				r.With(m.AuthTokenMiddleware).Post("/logout-all", h.LogoutAllHandler)
 			})
 			r.Route("/users", func(r chi.Router) {
+				r.Put("/activate/{token}", h.ActivateUser)
+				r.Put("/confirm-email/{token}", h.ConfirmEmailHandler)
+				r.With(m.AuthTokenMiddleware).Get("/me", h.GetMeHandler)
+				r.With(m.AuthTokenMiddleware).Patch("/me", h.UpdateMeHandler)
+				r.With(m.AuthTokenMiddleware).Get("/me/records", h.GetPersonalRecordsHandler)
+				r.With(m.AuthTokenMiddleware).Get("/me/stats", h.GetStatsHandler)
 				r.Route("/attributes", func(r chi.Router) {
 					r.Use(m.AuthTokenMiddleware)
 					r.Get("/", h.GetUserWithAttrHandler)
+					r.Patch("/", h.UpdateUserAttrHandler)
 					r.Post("/log/weight", h.LogWeightHandler)
 					r.Get("/weight", h.GetUserWeight)
 				})
+				r.Route("/measurements", func(r chi.Router) {
+					r.Use(m.AuthTokenMiddleware)
+					r.Post("/", h.LogMeasurementHandler)
+					r.Get("/", h.GetMeasurementsHandler)
+					r.Get("/latest", h.GetLatestMeasurementsHandler)
+				})
 			})
 			r.Route("/exercises", func(r chi.Router) {
 				r.With(m.AuthTokenMiddleware).Post("/", h.CreateExerciseHandler)
 				r.Get("/{exerciseID}", h.GetExerciseHandler)
+				r.With(m.AuthTokenMiddleware).
+					Get("/{exerciseID}/progress", h.GetExerciseProgressHandler)
 				r.Get("/", h.GetAllExercisesHandler)
 				r.With(m.AuthTokenMiddleware).Post("/{exerciseID}/like", h.LikeExerciseHandler)
 				r.With(m.AuthTokenMiddleware).
@@ -109,6 +162,19 @@ func (a *Application) Mount() http.Handler {
 			})
 			r.Route("/workouts", func(r chi.Router) {
 				r.With(m.AuthTokenMiddleware).Post("/end", h.EndWorkoutHandler)
+				r.Route("/active", func(r chi.Router) {
+					r.Use(m.AuthTokenMiddleware)
+					r.Post("/", h.StartWorkoutHandler)
+					r.Get("/", h.GetActiveWorkoutHandler)
+					r.Delete("/", h.AbandonWorkoutHandler)
+					r.Post("/pause", h.PauseWorkoutHandler)
+					r.Post("/resume", h.ResumeWorkoutHandler)
+					r.Post("/sets", h.AddActiveWorkoutSetHandler)
+					r.Post("/finish", h.FinishActiveWorkoutHandler)
+				})
+				r.With(m.AuthTokenMiddleware).Get("/history", h.GetFinishedWorkoutsHandler)
+				r.With(m.AuthTokenMiddleware).
+					Get("/history/{finishedWorkoutID}", h.GetFinishedWorkoutHandler)
 				r.Get("/{workoutID}", h.GetWorkoutHandler)
 				r.Get("/user/{userID}", h.GetUserWorkouts)
 				r.With(m.AuthTokenMiddleware).Post("/", h.CreateWorkoutHandler)
@@ -130,6 +196,32 @@ func (a *Application) Mount() http.Handler {
 			r.Route("/nutrients", func(r chi.Router) {
 				r.With(m.AuthTokenMiddleware).
 					Get("/daily-goal", h.GetMacronutrientsGoalPerDayHandler)
+				r.With(m.AuthTokenMiddleware).Get("/daily", h.GetDailyNutrientsHandler)
+				r.With(m.AuthTokenMiddleware).Post("/meals", h.LogMealHandler)
+				r.With(m.AuthTokenMiddleware).Delete("/meals/{mealID}", h.DeleteMealHandler)
+			})
+			r.Route("/foods", func(r chi.Router) {
+				r.With(m.AuthTokenMiddleware).Post("/", h.CreateFoodHandler)
+				r.Get("/", h.GetFoodsHandler)
+				r.Get("/search", h.SearchFoodsHandler)
+				r.Get("/barcode/{barcode}", h.GetFoodByBarcodeHandler)
+				r.Get("/{foodID}", h.GetFoodHandler)
+			})
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(m.AuthTokenMiddleware)
+				r.Use(m.RequireRole(store.RoleAdmin))
+				r.Get("/users", h.AdminGetUsersHandler)
+				r.Patch("/users/{userID}/deactivate", h.AdminDeactivateUserHandler)
+				r.Patch("/users/{userID}/role", h.AdminSetUserRoleHandler)
+				r.Patch("/exercises/{exerciseID}/visibility", h.AdminSetExerciseVisibilityHandler)
+				r.Delete("/exercises/{exerciseID}", h.AdminDeleteExerciseHandler)
+				r.Patch("/workouts/{workoutID}/visibility", h.AdminSetWorkoutVisibilityHandler)
+				r.Delete("/workouts/{workoutID}", h.AdminDeleteWorkoutHandler)
+				r.Patch(
+					"/reviews/workout/{workoutID}/user/{userID}/visibility",
+					h.AdminSetReviewVisibilityHandler,
+				)
+				r.Delete("/reviews/workout/{workoutID}/user/{userID}", h.AdminDeleteReviewHandler)
 			})
 		})
 	})
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+var (
+	ErrActiveWorkoutExists    = errors.New("user already has an active workout")
+	ErrNoActiveWorkout        = errors.New("user has no active workout")
+	ErrActiveWorkoutPaused    = errors.New("active workout is paused")
+	ErrActiveWorkoutNotPaused = errors.New("active workout is not paused")
+)
+
+type StartWorkoutPayload struct {
+	WorkoutID int64 `json:"workout_id" validate:"required"`
+}
+
+type ActiveWorkoutResponse struct {
+	store.ActiveWorkout
+	Duration int `json:"duration"`
+}
+
+//	@StartWorkout	godoc
+//	@Summary		Start workout
+//	@Description	Start a live workout session, a user can have only one at a time
+//	@Tags			active_workouts
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		StartWorkoutPayload	true	"Start workout payload"
+//	@Success		201		{object}	ActiveWorkoutResponse
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/workouts/active [post]
+func (h *Handlers) StartWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	var payload StartWorkoutPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	aw := &store.ActiveWorkout{
+		UserID:    u.ID,
+		WorkoutID: payload.WorkoutID,
+	}
+	if err := h.store.ActiveWorkouts.Start(r.Context(), aw); err != nil {
+		switch err {
+		case store.ErrConflict:
+			h.resp.ConflictError(w, r, ErrActiveWorkoutExists)
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.writeActiveWorkout(w, r, http.StatusCreated, aw)
+}
+
+//	@GetActiveWorkout	godoc
+//	@Summary			Get active workout
+//	@Description		Get the live workout session of the current user with its sets
+//	@Tags				active_workouts
+//	@Produce			json
+//	@Param				units	query		string	false	"Units override, metric or imperial"
+//	@Success			200		{object}	ActiveWorkoutResponse
+//	@Failure			404		{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/workouts/active [get]
+func (h *Handlers) GetActiveWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeActiveWorkout(w, r, http.StatusOK, aw)
+}
+
+//	@PauseWorkout	godoc
+//	@Summary		Pause workout
+//	@Description	Pause the live workout session, paused time does not count in the duration
+//	@Tags			active_workouts
+//	@Produce		json
+//	@Success		200	{object}	ActiveWorkoutResponse
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/workouts/active/pause [post]
+func (h *Handlers) PauseWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.ActiveWorkouts.Pause(r.Context(), aw.ID); err != nil {
+		switch err {
+		case store.ErrConflict:
+			h.resp.ConflictError(w, r, ErrActiveWorkoutPaused)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.GetActiveWorkoutHandler(w, r)
+}
+
+//	@ResumeWorkout	godoc
+//	@Summary		Resume workout
+//	@Description	Resume the paused live workout session
+//	@Tags			active_workouts
+//	@Produce		json
+//	@Success		200	{object}	ActiveWorkoutResponse
+//	@Failure		404	{object}	error
+//	@Failure		409	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/workouts/active/resume [post]
+func (h *Handlers) ResumeWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.ActiveWorkouts.Resume(r.Context(), aw.ID); err != nil {
+		switch err {
+		case store.ErrConflict:
+			h.resp.ConflictError(w, r, ErrActiveWorkoutNotPaused)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.GetActiveWorkoutHandler(w, r)
+}
+
+//	@AddActiveWorkoutSet	godoc
+//	@Summary				Log a set
+//	@Description			Append a set to the live workout session
+//	@Tags					active_workouts
+//	@Accept					json
+//	@Produce				json
+//	@Param					payload	body		WorkoutSetPayload	true	"Workout set payload"
+//	@Param					units	query		string				false	"Units override, metric or imperial"
+//	@Success				201		{object}	store.WorkoutSet
+//	@Failure				404		{object}	error
+//	@Failure				409		{object}	error
+//	@Security				ApiKeyAuth
+//	@Router					/workouts/active/sets [post]
+func (h *Handlers) AddActiveWorkoutSetHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var payload WorkoutSetPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if aw.IsPaused() {
+		h.resp.ConflictError(w, r, ErrActiveWorkoutPaused)
+		return
+	}
+
+	ws := &store.WorkoutSet{
+		ExerciseID: payload.ExerciseID,
+		Reps:       payload.Reps,
+		Weight:     units.ToKg(payload.Weight, system),
+		Duration:   payload.Duration,
+		RPE:        payload.RPE,
+	}
+	if err := h.store.ActiveWorkouts.AddSet(r.Context(), aw.ID, ws); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+	ws.Weight = units.Weight(ws.Weight, system)
+
+	if err := response.WriteJSON(w, http.StatusCreated, ws); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@FinishActiveWorkout	godoc
+//	@Summary				Finish workout
+//	@Description			Save the live workout session as a finished workout
+//	@Tags					active_workouts
+//	@Produce				json
+//	@Param					units	query		string	false	"Units override, metric or imperial"
+//	@Success				201		{object}	store.FinishedWorkout
+//	@Failure				404		{object}	error
+//	@Security				ApiKeyAuth
+//	@Router					/workouts/active/finish [post]
+func (h *Handlers) FinishActiveWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	fn, err := h.store.ActiveWorkouts.Finish(r.Context(), aw, time.Now())
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+	units.FinishedWorkout(fn, system)
+
+	if err := response.WriteJSON(w, http.StatusCreated, fn); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@AbandonWorkout	godoc
+//	@Summary		Abandon workout
+//	@Description	Discard the live workout session and its sets
+//	@Tags			active_workouts
+//	@Produce		json
+//	@Success		204	"No Content"
+//	@Failure		404	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/workouts/active [delete]
+func (h *Handlers) AbandonWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	aw, ok := h.getActiveWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.ActiveWorkouts.Delete(r.Context(), aw.ID))
+}
+
+// getActiveWorkout loads the active workout of the user from context. On
+// failure the error response is already written and false is returned.
+func (h *Handlers) getActiveWorkout(w http.ResponseWriter, r *http.Request) (*store.ActiveWorkout, bool) {
+	u := h.GetUserFromCtx(r)
+	aw, err := h.store.ActiveWorkouts.GetByUserID(r.Context(), u.ID)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, ErrNoActiveWorkout)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return aw, true
+}
+
+func (h *Handlers) writeActiveWorkout(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	aw *store.ActiveWorkout,
+) {
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	res := ActiveWorkoutResponse{
+		ActiveWorkout: *aw,
+		Duration:      int(aw.Duration(time.Now()).Seconds()),
+	}
+	res.Sets = append([]store.WorkoutSet(nil), aw.Sets...)
+	units.Sets(res.Sets, system)
+	if err := response.WriteJSON(w, status, res); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
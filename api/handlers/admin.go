@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type VisibilityPayload struct {
+	Hidden *bool `json:"hidden" validate:"required"`
+}
+
+type RolePayload struct {
+	Role string `json:"role" validate:"required,oneof=user coach admin"`
+}
+
+//	@AdminGetUsers	godoc
+//	@Summary		List users
+//	@Description	List users, searching by username or email
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort"
+//	@Param			search	query		string	false	"Search"
+//	@Success		200		{object}	[]store.User
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users [get]
+func (h *Handlers) AdminGetUsersHandler(w http.ResponseWriter, r *http.Request) {
+	fq := pagination.PaginatedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "asc",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(fq); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	users, err := h.store.Users.GetAll(r.Context(), fq)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, users); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@AdminDeactivateUser	godoc
+//	@Summary				Deactivate user
+//	@Description			Deactivate the user account and revoke all of its sessions
+//	@Tags					admin
+//	@Produce				json
+//	@Param					userID	path	int	true	"User ID"
+//	@Success				204		"No Content"
+//	@Failure				404		{object}	error
+//	@Security				ApiKeyAuth
+//	@Router					/admin/users/{userID}/deactivate [patch]
+func (h *Handlers) AdminDeactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := idParam(r, "userID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.Users.Deactivate(r.Context(), userID))
+}
+
+//	@AdminSetUserRole	godoc
+//	@Summary			Set user role
+//	@Description		Set the role of the user
+//	@Tags				admin
+//	@Accept				json
+//	@Produce			json
+//	@Param				userID	path	int			true	"User ID"
+//	@Param				payload	body	RolePayload	true	"Role Payload"
+//	@Success			204		"No Content"
+//	@Failure			404		{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/admin/users/{userID}/role [patch]
+func (h *Handlers) AdminSetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := idParam(r, "userID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var payload RolePayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.Users.SetRole(r.Context(), userID, payload.Role))
+}
+
+//	@AdminSetExerciseVisibility	godoc
+//	@Summary					Hide or show exercise
+//	@Description				Hide or show any exercise in public listings
+//	@Tags						admin
+//	@Accept						json
+//	@Produce					json
+//	@Param						exerciseID	path	int					true	"Exercise ID"
+//	@Param						payload		body	VisibilityPayload	true	"Visibility Payload"
+//	@Success					204			"No Content"
+//	@Failure					404			{object}	error
+//	@Security					ApiKeyAuth
+//	@Router						/admin/exercises/{exerciseID}/visibility [patch]
+func (h *Handlers) AdminSetExerciseVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID, err := idParam(r, "exerciseID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var payload VisibilityPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	err = h.store.Exercises.SetHidden(r.Context(), exerciseID, *payload.Hidden)
+	h.writeNoContentOrError(w, r, err)
+}
+
+//	@AdminDeleteExercise	godoc
+//	@Summary				Delete exercise
+//	@Description			Delete any exercise which is not used by workouts
+//	@Tags					admin
+//	@Produce				json
+//	@Param					exerciseID	path	int	true	"Exercise ID"
+//	@Success				204			"No Content"
+//	@Failure				404			{object}	error
+//	@Failure				409			{object}	ExerciseInUseResponse
+//	@Security				ApiKeyAuth
+//	@Router					/admin/exercises/{exerciseID} [delete]
+func (h *Handlers) AdminDeleteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	exerciseID, err := idParam(r, "exerciseID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.deleteExercise(w, r, exerciseID)
+}
+
+//	@AdminSetWorkoutVisibility	godoc
+//	@Summary					Hide or show workout
+//	@Description				Hide or show any workout in public listings
+//	@Tags						admin
+//	@Accept						json
+//	@Produce					json
+//	@Param						workoutID	path	int					true	"Workout ID"
+//	@Param						payload		body	VisibilityPayload	true	"Visibility Payload"
+//	@Success					204			"No Content"
+//	@Failure					404			{object}	error
+//	@Security					ApiKeyAuth
+//	@Router						/admin/workouts/{workoutID}/visibility [patch]
+func (h *Handlers) AdminSetWorkoutVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := idParam(r, "workoutID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var payload VisibilityPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	err = h.store.Workouts.SetHidden(r.Context(), workoutID, *payload.Hidden)
+	h.writeNoContentOrError(w, r, err)
+}
+
+//	@AdminDeleteWorkout	godoc
+//	@Summary			Delete workout
+//	@Description		Delete any workout together with its likes, reviews and finished workouts
+//	@Tags				admin
+//	@Produce			json
+//	@Param				workoutID	path	int	true	"Workout ID"
+//	@Success			204			"No Content"
+//	@Failure			404			{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/admin/workouts/{workoutID} [delete]
+func (h *Handlers) AdminDeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := idParam(r, "workoutID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.Workouts.Delete(r.Context(), workoutID))
+}
+
+//	@AdminSetReviewVisibility	godoc
+//	@Summary					Hide or show review
+//	@Description				Hide or show any workout review, hidden reviews do not count in the rating
+//	@Tags						admin
+//	@Accept						json
+//	@Produce					json
+//	@Param						workoutID	path	int					true	"Workout ID"
+//	@Param						userID		path	int					true	"Review author ID"
+//	@Param						payload		body	VisibilityPayload	true	"Visibility Payload"
+//	@Success					204			"No Content"
+//	@Failure					404			{object}	error
+//	@Security					ApiKeyAuth
+//	@Router						/admin/reviews/workout/{workoutID}/user/{userID}/visibility [patch]
+func (h *Handlers) AdminSetReviewVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := idParam(r, "workoutID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	userID, err := idParam(r, "userID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var payload VisibilityPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	err = h.store.Reviews.SetHidden(r.Context(), userID, workoutID, *payload.Hidden)
+	h.writeNoContentOrError(w, r, err)
+}
+
+//	@AdminDeleteReview	godoc
+//	@Summary			Delete review
+//	@Description		Delete any workout review
+//	@Tags				admin
+//	@Produce			json
+//	@Param				workoutID	path	int	true	"Workout ID"
+//	@Param				userID		path	int	true	"Review author ID"
+//	@Success			204			"No Content"
+//	@Failure			404			{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/admin/reviews/workout/{workoutID}/user/{userID} [delete]
+func (h *Handlers) AdminDeleteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	workoutID, err := idParam(r, "workoutID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	userID, err := idParam(r, "userID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.Reviews.Delete(r.Context(), userID, workoutID))
+}
+
+func (h *Handlers) writeNoContentOrError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case store.ErrNotFound:
+		h.resp.NotFoundErorr(w, r, err)
+	default:
+		h.resp.InternalServerError(w, r, err)
+	}
+}
+
+func idParam(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+}
@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+func TestAdminRequiresRole(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		ts.expect(t, http.StatusUnauthorized, http.MethodGet, "/admin/users", "", nil, nil)
+		ts.expect(t, http.StatusForbidden, http.MethodGet, "/admin/users", token, nil, nil)
+
+		ts.promote(t, token, store.RoleCoach)
+		ts.expect(t, http.StatusForbidden, http.MethodGet, "/admin/users", token, nil, nil)
+
+		ts.promote(t, token, store.RoleAdmin)
+		var users []struct {
+			Username string `json:"username"`
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/admin/users", token, nil, &users)
+		if len(users) != 1 || users[0].Username != "alice" {
+			t.Fatalf("got users %+v", users)
+		}
+	})
+}
+
+func TestAdminModeration(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		admin := ts.login(t, "alice")
+		ts.promote(t, admin, store.RoleAdmin)
+		token := ts.login(t, "bobby")
+		userID := ts.userID(t, token)
+		workoutID, exerciseID := ts.createWorkout(t, token, "legs day")
+
+		role := map[string]string{"role": store.RoleCoach}
+		ts.expect(t, http.StatusNoContent, http.MethodPatch, fmt.Sprintf("/admin/users/%d/role", userID), admin, role, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodPatch, fmt.Sprintf("/admin/users/%d/role", userID+1), admin, role, nil)
+
+		var inUse testExerciseInUse
+		exercise := fmt.Sprintf("/admin/exercises/%d", exerciseID)
+		ts.expect(t, http.StatusConflict, http.MethodDelete, exercise, admin, nil, &inUse)
+		if len(inUse.Workouts) != 1 || inUse.Workouts[0].ID != workoutID {
+			t.Fatalf("got exercise in use %+v", inUse)
+		}
+
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/admin/workouts/%d", workoutID), admin, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/admin/workouts/%d", workoutID), admin, nil, nil)
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, exercise, admin, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, exercise, admin, nil, nil)
+
+		ts.expect(t, http.StatusNoContent, http.MethodPatch, fmt.Sprintf("/admin/users/%d/deactivate", userID), admin, nil, nil)
+		ts.expect(t, http.StatusForbidden, http.MethodGet, "/users/me", token, nil, nil)
+	})
+}
@@ -1,22 +1,29 @@
 package handlers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
 var exp = time.Duration(time.Hour * 24 * 3)
 
+var (
+	ErrUserNotActive = errors.New("user is not activated")
+)
+
 type registerUserPayload struct {
 	Email      string  `json:"email"       validate:"required,email"`
 	Username   string  `json:"username"    validate:"required,min=4,max=20"`
@@ -26,11 +33,32 @@ type registerUserPayload struct {
 	Goal       string  `json:"goal"        validate:"required,oneof=lose gain maintain"`
 	WeightGoal float32 `json:"weight_goal"`
 	Weight     float32 `json:"weight"`
-	Age        int64   `json:"age"`
+	// BirthDate is formatted as 2006-01-02, Age is only used without it
+	BirthDate string `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
+	Age       int64  `json:"age"        validate:"gte=0,lte=120"`
+	// ActivityLevel and Formula default to moderate and mifflin
+	ActivityLevel string `json:"activity_level" validate:"omitempty,oneof=sedentary light moderate active very_active"`
+	Formula       string `json:"formula"        validate:"omitempty,oneof=mifflin harris_benedict"`
+	// Units is the preferred unit system, height, weight and weight_goal
+	// are read in it. Imperial heights are whole inches, 5 ft 10 in is 70
+	Units string `json:"units" validate:"omitempty,oneof=metric imperial"`
+}
+
+func (p registerUserPayload) birthDate() *time.Time {
+	if p.BirthDate != "" {
+		d, _ := time.Parse(time.DateOnly, p.BirthDate)
+		return &d
+	}
+	if p.Age > 0 {
+		d := time.Now().AddDate(-int(p.Age), 0, 0)
+		return &d
+	}
+	return nil
 }
 
 type TokenResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 // RegisterUserHandler godoc
@@ -41,7 +69,8 @@ type TokenResponse struct {
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		registerUserPayload	true	"Register User Payload"
-//	@Success		200		{object}	TokenResponse
+//	@Param			units	query		string				false	"Units override, metric or imperial"
+//	@Success		201		{object}	store.User
 //	@Router			/auth/register [post]
 func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 	var payload registerUserPayload
@@ -50,6 +79,18 @@ func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	if r.URL.Query().Get("units") == "" && payload.Units != "" {
+		system = payload.Units
+	}
+	payload.Height = units.HeightToCm(payload.Height, system)
+	payload.Weight = units.ToKg(payload.Weight, system)
+	payload.WeightGoal = units.ToKg(payload.WeightGoal, system)
+
 	if err := response.Validate.Struct(payload); err != nil {
 		h.resp.BadRequestError(w, r, err)
 		return
@@ -58,13 +99,16 @@ func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 	u := &store.User{
 		Email:    payload.Email,
 		Username: payload.Username,
+		Units:    payload.Units,
 		UserAttr: store.UserAttributes{
-			IsMale:     payload.IsMale,
-			Height:     payload.Height,
-			Goal:       payload.Goal,
-			WeightGoal: payload.WeightGoal,
-			Weight:     payload.Weight,
-			Age:        payload.Age,
+			IsMale:        payload.IsMale,
+			Height:        payload.Height,
+			Goal:          payload.Goal,
+			WeightGoal:    payload.WeightGoal,
+			Weight:        payload.Weight,
+			BirthDate:     payload.birthDate(),
+			ActivityLevel: payload.ActivityLevel,
+			Formula:       payload.Formula,
 		},
 	}
 	if err := u.Password.Set(payload.Password); err != nil {
@@ -72,13 +116,9 @@ func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plainToken := uuid.New().String()
-
-	// hash the token for storage but keep the plain token for email
-	hash := sha256.Sum256([]byte(plainToken))
-	hashToken := hex.EncodeToString(hash[:])
+	plainToken, hashToken := newInviteToken()
 
-	err := h.store.Users.CreateAndInvite(r.Context(), u, hashToken, exp)
+	err = h.store.Users.CreateAndInvite(r.Context(), u, hashToken, exp)
 	if err != nil {
 		switch err {
 		case store.ErrDuplicateEmail:
@@ -91,16 +131,70 @@ func (h *Handlers) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res := TokenResponse{
-		Token: plainToken,
+	h.workers.Go("verification email", func(context.Context) { h.sendVerifyUser(u, plainToken) })
+
+	res := *u
+	units.UserAttributes(&res.UserAttr, system)
+	if err := response.WriteJSON(w, http.StatusCreated, res); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
 	}
+}
 
-	// go mailer.SendVerifyUser(u.Username, h.config.Mail.Addr, plainToken, h.config.Mail)
+type ResendVerificationPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
 
-	if err := response.WriteJSON(w, http.StatusOK, res); err != nil {
+// ResendVerificationHandler godoc
+//
+//	@Summary		Resend verification email
+//	@Description	Send a new verification code if an inactive user with the email exists, the previous code stops working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	ResendVerificationPayload	true	"Resend Verification Payload"
+//	@Success		204
+//	@Router			/auth/resend-verification [post]
+func (h *Handlers) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResendVerificationPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	u, err := h.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil && err != store.ErrNotFound {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
+
+	// do not reveal which emails are registered or already active
+	if err == nil && !u.IsActive {
+		plainToken, hashToken := newInviteToken()
+		if err := h.store.Users.Reinvite(ctx, u.ID, hashToken, exp); err != nil {
+			h.resp.InternalServerError(w, r, err)
+			return
+		}
+
+		h.workers.Go("verification email", func(context.Context) { h.sendVerifyUser(u, plainToken) })
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newInviteToken returns the plain token for the email and its hash for storage.
+func newInviteToken() (string, string) {
+	plainToken := uuid.New().String()
+
+	hash := sha256.Sum256([]byte(plainToken))
+	return plainToken, hex.EncodeToString(hash[:])
+}
+
+func (h *Handlers) sendVerifyUser(u *store.User, plainToken string) {
+	if err := mailer.SendVerifyUser(h.mailer, u.Username, u.Email, plainToken); err != nil {
+		h.log.Errorw("cannot send verification email", "user_id", u.ID, "error", err)
+	}
 }
 
 type LoginPayload struct {
@@ -117,6 +211,7 @@ type LoginPayload struct {
 //	@Produce		json
 //	@Param			payload	body		LoginPayload	true	"Login Payload"
 //	@Success		200		{object}	TokenResponse
+//	@Failure		403		{object}	error
 //	@Router			/auth/login [post]
 func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var payload LoginPayload
@@ -146,23 +241,16 @@ func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"sub": u.ID,
-		"exp": time.Now().Add(h.config.Auth.Iat).Unix(),
-		"iat": time.Now().Unix(),
-		"nbf": time.Now().Unix(),
-		"iss": h.config.Auth.Aud,
-		"aud": h.config.Auth.Aud,
+	if !u.IsActive {
+		h.resp.ForbiddenError(w, r, ErrUserNotActive)
+		return
 	}
 
-	token, err := h.authenticator.GenerateToken(claims)
+	tokenStruct, err := h.issueTokens(r.Context(), u)
 	if err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
-	tokenStruct := TokenResponse{
-		Token: token,
-	}
 	if err := response.WriteJSON(w, http.StatusOK, tokenStruct); err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRegisterAndActivate(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		login := map[string]string{"email": "alice@example.com", "password": "password123"}
+
+		var u struct {
+			ID       int64  `json:"id"`
+			IsActive bool   `json:"is_active"`
+			Username string `json:"username"`
+		}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/auth/register", "", registerPayload("alice"), &u)
+		if u.ID == 0 || u.IsActive || u.Username != "alice" {
+			t.Fatalf("got registered user %+v", u)
+		}
+
+		ts.expect(t, http.StatusForbidden, http.MethodPost, "/auth/login", "", login, nil)
+
+		code := ts.code(t, "alice@example.com", "User Verification")
+		ts.expect(t, http.StatusNoContent, http.MethodPut, "/users/activate/"+code, "", nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodPut, "/users/activate/"+code, "", nil, nil)
+
+		var tokens struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/login", "", login, &tokens)
+		if tokens.Token == "" || tokens.RefreshToken == "" {
+			t.Fatalf("got tokens %+v", tokens)
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", tokens.Token, nil, nil)
+	})
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/auth/register", "", registerPayload("alice"), nil)
+
+		ts.expect(t, http.StatusBadRequest, http.MethodPost, "/auth/register", "", registerPayload("alice"), nil)
+
+		sameEmail := registerPayload("bobby")
+		sameEmail["email"] = "ALICE@example.com"
+		ts.expect(t, http.StatusBadRequest, http.MethodPost, "/auth/register", "", sameEmail, nil)
+
+		sameUsername := registerPayload("alice")
+		sameUsername["email"] = "bobby@example.com"
+		ts.expect(t, http.StatusBadRequest, http.MethodPost, "/auth/register", "", sameUsername, nil)
+	})
+}
+
+func TestActivateUnknownCode(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.expect(t, http.StatusNotFound, http.MethodPut, "/users/activate/unknown", "", nil, nil)
+	})
+}
+
+func TestResendVerification(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/auth/register", "", registerPayload("alice"), nil)
+		first := ts.code(t, "alice@example.com", "User Verification")
+
+		unknown := map[string]string{"email": "nobody@example.com"}
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/auth/resend-verification", "", unknown, nil)
+
+		body := map[string]string{"email": "alice@example.com"}
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/auth/resend-verification", "", body, nil)
+		var code string
+		for code = first; code == first; {
+			code = ts.code(t, "alice@example.com", "User Verification")
+		}
+		ts.expect(t, http.StatusNotFound, http.MethodPut, "/users/activate/"+first, "", nil, nil)
+		ts.expect(t, http.StatusNoContent, http.MethodPut, "/users/activate/"+code, "", nil, nil)
+
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/auth/resend-verification", "", body, nil)
+	})
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.login(t, "alice")
+
+		body := map[string]string{"email": "alice@example.com", "password": "wrong-password"}
+		ts.expect(t, http.StatusUnauthorized, http.MethodPost, "/auth/login", "", body, nil)
+	})
+}
+
+func TestMeRequiresToken(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.expect(t, http.StatusUnauthorized, http.MethodGet, "/users/me", "", nil, nil)
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -12,6 +13,11 @@ import (
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
+var (
+	ErrNotExerciseOwner = errors.New("only the owner can modify this exercise")
+	ErrExerciseInUse    = errors.New("exercise is used by workouts or logged sets")
+)
+
 //	@GetAllExercises	godoc
 //	@Summary			Get all Exercises
 //	@Description		Get all Exercises
@@ -124,6 +130,10 @@ func (h *Handlers) GetExerciseHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if e.IsHidden {
+		h.resp.NotFoundErorr(w, r, store.ErrNotFound)
+		return
+	}
 
 	if err := response.WriteJSON(w, http.StatusOK, e); err != nil {
 		h.resp.InternalServerError(w, r, err)
@@ -178,29 +188,180 @@ func (h *Handlers) GetUsersExercises(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type UpdateExercisePayload struct {
+	Name         *string  `json:"name"          validate:"omitnil,min=1,max=255"`
+	Description  *string  `json:"description"`
+	IsDuration   *bool    `json:"is_duration"`
+	Duration     *int     `json:"duration"      validate:"omitnil,gte=0"`
+	TutorialLink *string  `json:"tutorial_link"`
+	Muscles      []string `json:"muscles"       validate:"omitnil,min=1"`
+}
+
 //	@UpdateExerciseHandler	godoc
-//	@Summary				Update Exercise by idString
-//	@Description			Update Exercise by idString
+//	@Summary				Update Exercise by id
+//	@Description			Update Exercise by id, only the owner can update it
 //	@Tags					exercises
 //	@Accept					json
 //	@Produce				json
-//	@Param					idString	path		int				true	"Exercise ID"
-//	@Param					payload		body		store.Exercise	true	"Exercise Payload"
+//	@Param					exerciseID	path		int						true	"Exercise ID"
+//	@Param					payload		body		UpdateExercisePayload	true	"Update Exercise Payload"
 //	@Success				200			{object}	store.Exercise
+//	@Failure				403			{object}	error
+//	@Failure				404			{object}	error
 //	@Security				ApiKeyAuth
-//	@Router					/exercises/{idString} [patch]
+//	@Router					/exercises/{exerciseID} [patch]
 func (h *Handlers) UpdateExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.getOwnedExercise(w, r)
+	if !ok {
+		return
+	}
+
+	var payload UpdateExercisePayload
+	if err := response.ReadJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if payload.Name != nil {
+		e.Name = *payload.Name
+	}
+	if payload.Description != nil {
+		e.Description = *payload.Description
+	}
+	if payload.IsDuration != nil {
+		e.IsDuration = *payload.IsDuration
+	}
+	if payload.Duration != nil {
+		e.Duration = *payload.Duration
+	}
+	if payload.TutorialLink != nil {
+		e.TutorialLink = *payload.TutorialLink
+	}
+	if payload.Muscles != nil {
+		e.Muscles = payload.Muscles
+	}
+
+	if err := h.store.Exercises.Update(r.Context(), e); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, e); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+type ExerciseInUseResponse struct {
+	Error    string          `json:"error"`
+	Workouts []store.Workout `json:"workouts"`
 }
 
 //	@DeleteExerciseHandler	godoc
-//	@Summary				Delete Exercise by idString
-//	@Description			Delete Exercise by idString
+//	@Summary				Delete Exercise by id
+//	@Description			Delete Exercise by id, only the owner can delete it and only while no workout uses it
 //	@Tags					exercises
 //	@Accept					json
 //	@Produce				json
-//	@Param					idString	path		int	true	"Exercise ID"
-//	@Success				204			{object}	store.Exercise
+//	@Param					exerciseID	path	int	true	"Exercise ID"
+//	@Success				204			"No Content"
+//	@Failure				403			{object}	error
+//	@Failure				404			{object}	error
+//	@Failure				409			{object}	ExerciseInUseResponse
 //	@Security				ApiKeyAuth
-//	@Router					/exercises/{idString} [delete]
+//	@Router					/exercises/{exerciseID} [delete]
 func (h *Handlers) DeleteExerciseHandler(w http.ResponseWriter, r *http.Request) {
+	e, ok := h.getOwnedExercise(w, r)
+	if !ok {
+		return
+	}
+
+	h.deleteExercise(w, r, e.ID)
+}
+
+// deleteExercise deletes the exercise, answering with the workouts which
+// use it when it is in use.
+func (h *Handlers) deleteExercise(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx := r.Context()
+	workouts, err := h.store.Exercises.GetDependentWorkouts(ctx, id)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	if len(workouts) > 0 {
+		h.writeExerciseInUse(w, r, workouts)
+		return
+	}
+
+	if err := h.store.Exercises.Delete(ctx, id); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		case store.ErrConflict:
+			// a workout started using the exercise after the check above
+			workouts, err := h.store.Exercises.GetDependentWorkouts(ctx, id)
+			if err != nil {
+				h.resp.InternalServerError(w, r, err)
+				return
+			}
+			h.writeExerciseInUse(w, r, workouts)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) writeExerciseInUse(w http.ResponseWriter, r *http.Request, workouts []store.Workout) {
+	res := ExerciseInUseResponse{
+		Error:    ErrExerciseInUse.Error(),
+		Workouts: workouts,
+	}
+	if err := response.WriteJSON(w, http.StatusConflict, res); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// getOwnedExercise loads the exercise from the exerciseID url param and checks
+// that it belongs to the user from context. On failure the error response is
+// already written and false is returned.
+func (h *Handlers) getOwnedExercise(w http.ResponseWriter, r *http.Request) (*store.Exercise, bool) {
+	u := h.GetUserFromCtx(r)
+	idString := chi.URLParam(r, "exerciseID")
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return nil, false
+	}
+
+	e, err := h.store.Exercises.GetByID(r.Context(), id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if e.UserID != u.ID {
+		h.resp.ForbiddenError(w, r, ErrNotExerciseOwner)
+		return nil, false
+	}
+
+	return e, true
 }
@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type testExerciseInUse struct {
+	Error    string `json:"error"`
+	Workouts []struct {
+		ID int64 `json:"id"`
+	} `json:"workouts"`
+}
+
+func TestDeleteExercise(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		other := ts.login(t, "bobby")
+		workoutID, exerciseID := ts.createWorkout(t, token, "legs day")
+		path := fmt.Sprintf("/exercises/%d", exerciseID)
+
+		ts.expect(t, http.StatusForbidden, http.MethodDelete, path, other, nil, nil)
+
+		var inUse testExerciseInUse
+		ts.expect(t, http.StatusConflict, http.MethodDelete, path, token, nil, &inUse)
+		if len(inUse.Workouts) != 1 || inUse.Workouts[0].ID != workoutID {
+			t.Fatalf("got exercise in use %+v", inUse)
+		}
+
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, fmt.Sprintf("/workouts/%d", workoutID), token, nil, nil)
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, token, nil, nil)
+	})
+}
+
+func TestLikeExercise(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		_, exerciseID := ts.createWorkout(t, token, "legs day")
+		path := fmt.Sprintf("/exercises/%d/like", exerciseID)
+
+		ts.expect(t, http.StatusOK, http.MethodPost, path, token, nil, nil)
+		ts.expect(t, http.StatusBadRequest, http.MethodPost, path, token, nil, nil)
+		ts.expect(t, http.StatusOK, http.MethodDelete, path, token, nil, nil)
+	})
+}
+
+func TestDeleteExerciseInActiveWorkout(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		workoutID, _ := ts.createWorkout(t, token, "legs day")
+
+		var exercise struct {
+			ID int64 `json:"id"`
+		}
+		body := map[string]any{"name": "lunge", "description": "legs", "muscles": []string{"legs"}}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/exercises", token, body, &exercise)
+
+		start := map[string]any{"workout_id": workoutID}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active", token, start, nil)
+		set := map[string]any{"exercise_id": exercise.ID, "reps": 10, "weight": 20}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active/sets", token, set, nil)
+
+		var inUse testExerciseInUse
+		path := fmt.Sprintf("/exercises/%d", exercise.ID)
+		ts.expect(t, http.StatusConflict, http.MethodDelete, path, token, nil, &inUse)
+		if len(inUse.Workouts) != 1 || inUse.Workouts[0].ID != workoutID {
+			t.Fatalf("got exercise in use %+v", inUse)
+		}
+
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, "/workouts/active", token, nil, nil)
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+	})
+}
+
+func TestListExercises(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		for _, body := range []map[string]any{
+			{"name": "Squat", "description": "legs", "muscles": []string{"legs", "glutes"}},
+			{"name": "Bench press", "description": "chest", "muscles": []string{"chest", "arms"}},
+			{"name": "Curl", "description": "biceps", "muscles": []string{"arms"}},
+		} {
+			ts.expect(t, http.StatusCreated, http.MethodPost, "/exercises", token, body, nil)
+		}
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"", []string{"Bench press", "Curl", "Squat"}},
+			{"?tags=arms", []string{"Bench press", "Curl"}},
+			{"?tags=chest,arms", []string{"Bench press"}},
+			{"?tags=legs,arms", []string{}},
+			{"?search=BENCH", []string{"Bench press"}},
+			{"?search=ps&tags=arms", []string{"Curl"}},
+		}
+		for _, tt := range tests {
+			var exercises []struct {
+				Name string `json:"name"`
+			}
+			ts.expect(t, http.StatusOK, http.MethodGet, "/exercises"+tt.query, "", nil, &exercises)
+			names := make([]string, 0, len(exercises))
+			for _, e := range exercises {
+				names = append(names, e.Name)
+			}
+			// exercises created in the same second have no defined order
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("GET /exercises%s: got %v, want %v", tt.query, names, tt.want)
+			}
+		}
+	})
+}
@@ -1,31 +1,55 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
+var ErrNotFinishedWorkoutOwner = errors.New("finished workout belongs to another user")
+
 type EndWorkoutPayload struct {
-	WorkoutID int64  `json:"workout_id"`
-	Duration  string `json:"duration"`
+	WorkoutID int64               `json:"workout_id" validate:"required"`
+	StartedAt time.Time           `json:"started_at" validate:"required"`
+	EndedAt   time.Time           `json:"ended_at"   validate:"required,gtfield=StartedAt"`
+	Sets      []WorkoutSetPayload `json:"sets"       validate:"dive"`
+}
+
+// WorkoutSetPayload is a logged set, duration is in seconds.
+type WorkoutSetPayload struct {
+	ExerciseID int64    `json:"exercise_id" validate:"required"`
+	Reps       int      `json:"reps"        validate:"gte=0"`
+	Weight     float32  `json:"weight"      validate:"gte=0"`
+	Duration   int      `json:"duration"    validate:"gte=0"`
+	RPE        *float32 `json:"rpe"         validate:"omitnil,gte=1,lte=10"`
 }
 
 //	@EndWorkout		godoc
 //	@Summary		End workout
-//	@Description	End workout
+//	@Description	Save a finished workout session with its logged sets, sets are numbered per exercise in the given order. Personal records beaten by the session are returned in new_records
 //	@Tags			finished_workouts
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		EndWorkoutPayload	true	"End workout payload"
-//	@Success		200		{string}	string				"ok"
-//	@Failure		400		{string}	string				"bad request"
-//	@Failure		500		{string}	string				"internal server error"
+//	@Param			units	query		string				false	"Units override, metric or imperial"
+//	@Success		201		{object}	store.FinishedWorkout
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/workouts/end [post]
 func (h Handlers) EndWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
 
 	var payload EndWorkoutPayload
 	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
@@ -35,18 +59,147 @@ func (h Handlers) EndWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	fn := store.FinishedWorkout{
 		WorkoutID: payload.WorkoutID,
-		Duration:  payload.Duration,
 		UserID:    u.ID,
+		StartedAt: payload.StartedAt,
+		EndedAt:   payload.EndedAt,
+		Duration:  int(payload.EndedAt.Sub(payload.StartedAt).Seconds()),
+		Sets:      newWorkoutSets(payload.Sets, system),
+	}
+
+	if err := h.store.FinishedWorkouts.Create(r.Context(), &fn); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+	units.FinishedWorkout(&fn, system)
+
+	if err := response.WriteJSON(w, http.StatusCreated, fn); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+type FinishedWorkoutsResponse struct {
+	Data       []store.FinishedWorkout `json:"data"`
+	TotalCount int                     `json:"total_count"`
+}
+
+//	@GetFinishedWorkouts	godoc
+//	@Summary				Get workout history
+//	@Description			Get the finished workouts of the current user, without their sets
+//	@Tags					finished_workouts
+//	@Accept					json
+//	@Produce				json
+//	@Param					limit	query		int		false	"Limit"
+//	@Param					offset	query		int		false	"Offset"
+//	@Param					sort	query		string	false	"Sort"
+//	@Success				200		{object}	FinishedWorkoutsResponse
+//	@Security				ApiKeyAuth
+//	@Router					/workouts/history [get]
+func (h *Handlers) GetFinishedWorkoutsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	fq := pagination.PaginatedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "desc",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(fq); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
 	}
 
-	err := h.store.FinishedWorkouts.Create(r.Context(), &fn)
+	fws, totalCount, err := h.store.FinishedWorkouts.GetAll(r.Context(), fq, u.ID)
 	if err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
 
-	if err := response.WriteSuccess(w); err != nil {
+	resp := FinishedWorkoutsResponse{
+		Data:       fws,
+		TotalCount: totalCount,
+	}
+	if err := response.WriteJSON(w, http.StatusOK, resp); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@GetFinishedWorkout	godoc
+//	@Summary			Get finished workout
+//	@Description		Get a finished workout of the current user with its sets
+//	@Tags				finished_workouts
+//	@Accept				json
+//	@Produce			json
+//	@Param				finishedWorkoutID	path		int		true	"Finished workout ID"
+//	@Param				units				query		string	false	"Units override, metric or imperial"
+//	@Success			200					{object}	store.FinishedWorkout
+//	@Failure			403					{object}	error
+//	@Failure			404					{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/workouts/history/{finishedWorkoutID} [get]
+func (h *Handlers) GetFinishedWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	id, err := idParam(r, "finishedWorkoutID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	fn, err := h.store.FinishedWorkouts.GetByID(r.Context(), id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if fn.UserID != u.ID {
+		h.resp.ForbiddenError(w, r, ErrNotFinishedWorkoutOwner)
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	units.FinishedWorkout(fn, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, fn); err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
 }
+
+// newWorkoutSets numbers the sets of every exercise starting from 1 in the
+// order they were sent. Weights are converted from system to kilograms.
+func newWorkoutSets(payload []WorkoutSetPayload, system string) []store.WorkoutSet {
+	setNumbers := make(map[int64]int)
+	sets := make([]store.WorkoutSet, 0, len(payload))
+	for _, p := range payload {
+		setNumbers[p.ExerciseID]++
+		sets = append(sets, store.WorkoutSet{
+			ExerciseID: p.ExerciseID,
+			SetNumber:  setNumbers[p.ExerciseID],
+			Reps:       p.Reps,
+			Weight:     units.ToKg(p.Weight, system),
+			Duration:   p.Duration,
+			RPE:        p.RPE,
+		})
+	}
+	return sets
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+var (
+	ErrDuplicateBarcode = errors.New("a food with this barcode already exists")
+	ErrEmptySearch      = errors.New("search is required")
+)
+
+//	@CreateFood		godoc
+//	@Summary		Create a food
+//	@Description	Add a food to the catalog, macronutrients are per 100 grams
+//	@Tags			foods
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		store.Food	true	"Food payload"
+//	@Success		201		{object}	store.Food
+//	@Failure		400		{object}	error
+//	@Failure		409		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/foods [post]
+func (h *Handlers) CreateFoodHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	var payload store.Food
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	payload.UserID = &u.ID
+	if payload.Barcode != nil {
+		if code := strings.TrimLeft(*payload.Barcode, "0"); code != "" {
+			payload.Barcode = &code
+		} else {
+			payload.Barcode = nil
+		}
+	}
+
+	if err := h.store.Foods.Create(r.Context(), &payload); err != nil {
+		switch err {
+		case store.ErrConflict:
+			h.resp.ConflictError(w, r, ErrDuplicateBarcode)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusCreated, payload); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@GetFoods		godoc
+//	@Summary		Get foods
+//	@Description	Search the foods catalog by name
+//	@Tags			foods
+//	@Produce		json
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Param			sort	query		string	false	"Sort"
+//	@Param			search	query		string	false	"Search"
+//	@Success		200		{object}	[]store.Food
+//	@Router			/foods [get]
+func (h *Handlers) GetFoodsHandler(w http.ResponseWriter, r *http.Request) {
+	fq := pagination.PaginatedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "asc",
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(fq); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	foods, err := h.store.Foods.GetAll(r.Context(), fq)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, foods); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@GetFood		godoc
+//	@Summary		Get a food
+//	@Description	Get a food of the catalog by ID
+//	@Tags			foods
+//	@Produce		json
+//	@Param			foodID	path		int	true	"Food ID"
+//	@Success		200		{object}	store.Food
+//	@Failure		404		{object}	error
+//	@Router			/foods/{foodID} [get]
+func (h *Handlers) GetFoodHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := idParam(r, "foodID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	f, err := h.store.Foods.GetByID(r.Context(), id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, f); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@SearchFoods	godoc
+//	@Summary		Search foods
+//	@Description	Fuzzy search the foods catalog by name, best matches first
+//	@Tags			foods
+//	@Produce		json
+//	@Param			search	query		string	true	"Search"
+//	@Param			limit	query		int		false	"Limit"
+//	@Param			offset	query		int		false	"Offset"
+//	@Success		200		{object}	[]store.Food
+//	@Failure		400		{object}	error
+//	@Router			/foods/search [get]
+func (h *Handlers) SearchFoodsHandler(w http.ResponseWriter, r *http.Request) {
+	fq := pagination.PaginatedQuery{
+		Limit:  20,
+		Offset: 0,
+	}
+
+	fq, err := fq.Parse(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(fq); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	if fq.Search == "" {
+		h.resp.BadRequestError(w, r, ErrEmptySearch)
+		return
+	}
+
+	foods, err := h.store.Foods.Search(r.Context(), fq)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, foods); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@GetFoodByBarcode	godoc
+//	@Summary			Get a food by barcode
+//	@Description		Get a food of the catalog by its barcode, leading zeros are ignored
+//	@Tags				foods
+//	@Produce			json
+//	@Param				barcode	path		string	true	"Barcode"
+//	@Success			200		{object}	store.Food
+//	@Failure			404		{object}	error
+//	@Router				/foods/barcode/{barcode} [get]
+func (h *Handlers) GetFoodByBarcodeHandler(w http.ResponseWriter, r *http.Request) {
+	barcode := strings.TrimLeft(chi.URLParam(r, "barcode"), "0")
+
+	f, err := h.store.Foods.GetByBarcode(r.Context(), barcode)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, f); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
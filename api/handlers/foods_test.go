@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testFood struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestSearchFoods(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		for _, name := range []string{"Oatmeal", "Oat milk", "Banana"} {
+			body := map[string]any{"name": name, "calories": 100, "proteins": 1, "fats": 1, "carbohydrates": 20}
+			ts.expect(t, http.StatusCreated, http.MethodPost, "/foods", token, body, nil)
+		}
+
+		var foods []testFood
+		ts.expect(t, http.StatusOK, http.MethodGet, "/foods?search=OAT", "", nil, &foods)
+		if len(foods) != 2 || foods[0].Name != "Oat milk" || foods[1].Name != "Oatmeal" {
+			t.Fatalf("got foods %+v", foods)
+		}
+
+		ts.expect(t, http.StatusOK, http.MethodGet, "/foods/search?search=banan", "", nil, &foods)
+		if len(foods) != 1 || foods[0].Name != "Banana" {
+			t.Fatalf("got similar foods %+v", foods)
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/foods/search?search=oatmeel", "", nil, &foods)
+		if len(foods) == 0 || foods[0].Name != "Oatmeal" {
+			t.Fatalf("got similar foods %+v", foods)
+		}
+
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, "/foods/search", "", nil, nil)
+	})
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"go.uber.org/zap"
+
 	"github.com/stanislavCasciuc/atom-fit/api/response"
 	"github.com/stanislavCasciuc/atom-fit/internal/auth"
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/workers"
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
@@ -12,6 +16,9 @@ type Handlers struct {
 	store         store.Storage
 	config        config.Config
 	authenticator auth.Authenticator
+	mailer        mailer.Mailer
+	log           *zap.SugaredLogger
+	workers       *workers.Registry
 }
 
 func New(
@@ -19,11 +26,17 @@ func New(
 	store store.Storage,
 	config config.Config,
 	authenticator auth.Authenticator,
+	mailer mailer.Mailer,
+	log *zap.SugaredLogger,
+	workers *workers.Registry,
 ) *Handlers {
 	return &Handlers{
 		resp,
 		store,
 		config,
 		authenticator,
+		mailer,
+		log,
+		workers,
 	}
 }
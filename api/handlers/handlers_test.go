@@ -0,0 +1,224 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stanislavCasciuc/atom-fit/api"
+	"github.com/stanislavCasciuc/atom-fit/db"
+	"github.com/stanislavCasciuc/atom-fit/internal/auth"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/workers"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+	"github.com/stanislavCasciuc/atom-fit/internal/store/memstore"
+	"github.com/stanislavCasciuc/atom-fit/internal/store/sqlitestore"
+)
+
+var codeRe = regexp.MustCompile(`code is: (\S+)`)
+
+// stores are the storages every test runs against.
+var stores = []struct {
+	name string
+	new  func(t *testing.T) store.Storage
+}{
+	{"memory", func(*testing.T) store.Storage { return memstore.New() }},
+	{"sqlite", newSQLiteStore},
+}
+
+// eachStore runs the test against a new server over each of the stores.
+func eachStore(t *testing.T, test func(t *testing.T, ts *testServer)) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			test(t, newTestServer(t, s.new(t)))
+		})
+	}
+}
+
+// newSQLiteStore returns a store over a migrated in-memory SQLite database.
+func newSQLiteStore(t *testing.T) store.Storage {
+	t.Helper()
+
+	conn, err := db.New("sqlite://:memory:", 1, 1, "15m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	migrator, err := db.NewMigrator(conn, db.DriverSQLite, db.SQLiteMigrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return sqlitestore.New(conn)
+}
+
+// testServer serves the API over a store and keeps the sent emails.
+type testServer struct {
+	*httptest.Server
+	store  store.Storage
+	mailer *mailer.MemoryMailer
+}
+
+func newTestServer(t *testing.T, s store.Storage) *testServer {
+	t.Helper()
+
+	cfg := config.Default()
+	log := zap.NewNop().Sugar()
+	app := &api.Application{
+		Config:        cfg,
+		Log:           log,
+		Store:         s,
+		Mailer:        mailer.NewMemory(),
+		Authenticator: auth.New(cfg.Auth.Secret, cfg.Auth.Aud),
+		Workers:       workers.New(log),
+	}
+
+	ts := &testServer{
+		Server: httptest.NewServer(app.Mount()),
+		store:  app.Store,
+		mailer: app.Mailer.(*mailer.MemoryMailer),
+	}
+	t.Cleanup(func() {
+		ts.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := app.Workers.Stop(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return ts
+}
+
+// do sends the request with body encoded as JSON, authenticated by token
+// when it is set, and decodes the response, successful or not, into res when
+// it is not nil.
+func (ts *testServer) do(t *testing.T, method, path, token string, body, res any) int {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, ts.URL+"/api/v1"+path, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if res != nil {
+		if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+			t.Fatalf("%s %s: cannot decode the response: %v", method, path, err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+// expect fails the test when the request does not answer with status.
+func (ts *testServer) expect(t *testing.T, status int, method, path, token string, body, res any) {
+	t.Helper()
+
+	if got := ts.do(t, method, path, token, body, res); got != status {
+		t.Fatalf("%s %s: got status %d, want %d", method, path, got, status)
+	}
+}
+
+// code waits for the email with the subject sent to the address and
+// returns the code in the latest one.
+func (ts *testServer) code(t *testing.T, to, subject string) string {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		msgs := ts.mailer.Messages()
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].To[0] != to || msgs[i].Subject != subject {
+				continue
+			}
+			if m := codeRe.FindStringSubmatch(msgs[i].Body); m != nil {
+				return m[1]
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("no %q code was sent to %s", subject, to)
+	return ""
+}
+
+func registerPayload(name string) map[string]any {
+	return map[string]any{
+		"email":       name + "@example.com",
+		"username":    name,
+		"password":    "password123",
+		"is_male":     true,
+		"height":      180,
+		"goal":        "maintain",
+		"weight_goal": 80,
+		"weight":      80,
+		"birth_date":  "1990-01-02",
+	}
+}
+
+// login registers and activates a user and returns its access token.
+func (ts *testServer) login(t *testing.T, name string) string {
+	t.Helper()
+
+	email := name + "@example.com"
+	ts.expect(t, http.StatusCreated, http.MethodPost, "/auth/register", "", registerPayload(name), nil)
+	code := ts.code(t, email, "User Verification")
+	ts.expect(t, http.StatusNoContent, http.MethodPut, "/users/activate/"+code, "", nil, nil)
+
+	var tokens struct {
+		Token string `json:"token"`
+	}
+	body := map[string]string{"email": email, "password": "password123"}
+	ts.expect(t, http.StatusOK, http.MethodPost, "/auth/login", "", body, &tokens)
+
+	return tokens.Token
+}
+
+// userID returns the id of the user authenticated by token.
+func (ts *testServer) userID(t *testing.T, token string) int64 {
+	t.Helper()
+
+	var me struct {
+		ID int64 `json:"id"`
+	}
+	ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", token, nil, &me)
+
+	return me.ID
+}
+
+// promote sets the role of the user authenticated by token.
+func (ts *testServer) promote(t *testing.T, token, role string) {
+	t.Helper()
+
+	if err := ts.store.Users.SetRole(context.Background(), ts.userID(t, token), role); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+)
+
+// JWKSHandler godoc
+//
+//	@Summary		JSON Web Key Set
+//	@Description	Public keys to verify access tokens, selected by the kid header
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	auth.JWKSet
+//	@Router			/.well-known/jwks.json [get]
+func (h *Handlers) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := response.WriteJSON(w, http.StatusOK, h.authenticator.JWKS()); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// defaultMeasurementsWindow is used when the query has no since.
+const defaultMeasurementsWindow = 90 * 24 * time.Hour
+
+// The bounds of a logged body fat, the same as the body_fat attribute.
+const (
+	minBodyFat = 3
+	maxBodyFat = 70
+)
+
+var (
+	ErrUnknownMetric = errors.New("unknown metric")
+	ErrBodyFatRange  = fmt.Errorf("body fat must be between %d%% and %d%%", minBodyFat, maxBodyFat)
+)
+
+type LogMeasurementPayload struct {
+	Metric string  `json:"metric" validate:"required,oneof=body_fat waist chest hips arms thighs resting_heart_rate"`
+	Value  float32 `json:"value"  validate:"gt=0"`
+	// Unit defaults to the unit of the metric: % for body_fat, bpm for
+	// resting_heart_rate and cm or in, by the unit system, for the rest
+	Unit string `json:"unit"`
+	// Date is formatted as 2006-01-02, today when empty
+	Date string `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// LogMeasurementHandler godoc
+//
+//	@Summary		Log a measurement
+//	@Description	Log a body measurement, logging the same metric twice a day replaces the value
+//	@Tags			measurements
+//	@Accept			json
+//	@Param			payload	body	LogMeasurementPayload	true	"Measurement payload"
+//	@Param			units	query	string					false	"Units override, metric or imperial"
+//	@Success		204		"No Content"
+//	@Failure		400		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/measurements [post]
+func (h *Handlers) LogMeasurementHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	var payload LogMeasurementPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	unit := store.MetricUnits[payload.Metric]
+	if unit == units.LengthUnit(units.Metric) {
+		switch payload.Unit {
+		case "":
+			payload.Value = units.ToCm(payload.Value, system)
+		case units.LengthUnit(units.Imperial):
+			payload.Value = units.ToCm(payload.Value, units.Imperial)
+		case unit:
+		default:
+			h.resp.BadRequestError(w, r, fmt.Errorf("%s is measured in cm or in", payload.Metric))
+			return
+		}
+	} else if payload.Unit != "" && payload.Unit != unit {
+		h.resp.BadRequestError(w, r, fmt.Errorf("%s is measured in %s", payload.Metric, unit))
+		return
+	}
+	if payload.Metric == store.MetricBodyFat && (payload.Value < minBodyFat || payload.Value > maxBodyFat) {
+		h.resp.BadRequestError(w, r, ErrBodyFatRange)
+		return
+	}
+
+	date := time.Now()
+	if payload.Date != "" {
+		date, _ = time.Parse(time.DateOnly, payload.Date)
+	}
+
+	m := &store.Measurement{
+		UserID: u.ID,
+		Metric: payload.Metric,
+		Date:   date,
+		Value:  payload.Value,
+		Unit:   unit,
+	}
+	if err := h.store.Measurements.Add(r.Context(), m); err != nil {
+		if err == store.ErrConflict {
+			err = h.store.Measurements.Update(r.Context(), m)
+			if err != nil {
+				h.resp.InternalServerError(w, r, err)
+				return
+			} else {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMeasurementsHandler godoc
+//
+//	@Summary		Get measurements
+//	@Description	Get the measurements between since and until, by default the last 90 days, oldest first
+//	@Tags			measurements
+//	@Produce		json
+//	@Param			metric	query		string	false	"Metric, all metrics when empty"
+//	@Param			since	query		string	false	"Since, 2006-01-02 15:04:05"
+//	@Param			until	query		string	false	"Until, 2006-01-02 15:04:05"
+//	@Param			units	query		string	false	"Units override, metric or imperial"
+//	@Success		200		{object}	[]store.Measurement
+//	@Failure		400		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/measurements [get]
+func (h *Handlers) GetMeasurementsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	metric := r.URL.Query().Get("metric")
+	if _, ok := store.MetricUnits[metric]; metric != "" && !ok {
+		h.resp.BadRequestError(w, r, ErrUnknownMetric)
+		return
+	}
+
+	until, err := timeQuery(r, "until", time.Now())
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	since, err := timeQuery(r, "since", until.Add(-defaultMeasurementsWindow))
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	if since.After(until) {
+		h.resp.BadRequestError(w, r, ErrInvalidWindow)
+		return
+	}
+
+	measurements, err := h.store.Measurements.GetRange(r.Context(), u.ID, metric, since, until)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	units.Measurements(measurements, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, measurements); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// GetLatestMeasurementsHandler godoc
+//
+//	@Summary		Get latest measurements
+//	@Description	Get the last logged value of every metric
+//	@Tags			measurements
+//	@Produce		json
+//	@Param			units	query		string	false	"Units override, metric or imperial"
+//	@Success		200		{object}	[]store.Measurement
+//	@Security		ApiKeyAuth
+//	@Router			/users/measurements/latest [get]
+func (h *Handlers) GetLatestMeasurementsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	measurements, err := h.store.Measurements.GetLatest(r.Context(), u.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	units.Measurements(measurements, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, measurements); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// loadBodyFat sets the body fat of the attributes to the last one measured
+// on or before the date, the attribute is kept when none was logged.
+func (h *Handlers) loadBodyFat(ctx context.Context, ua *store.UserAttributes, date time.Time) error {
+	m, err := h.store.Measurements.GetLatestAt(ctx, ua.UserID, store.MetricBodyFat, date)
+	switch err {
+	case nil:
+		ua.BodyFat = &m.Value
+		return nil
+	case store.ErrNotFound:
+		return nil
+	default:
+		return err
+	}
+}
@@ -0,0 +1,48 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testMeasurement struct {
+	Metric string  `json:"metric"`
+	Value  float32 `json:"value"`
+	Unit   string  `json:"unit"`
+}
+
+func TestMeasurements(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		fat := map[string]any{"metric": "body_fat", "value": 70, "date": "2026-01-02"}
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/users/measurements", token, fat, nil)
+		waist := map[string]any{"metric": "waist", "value": 80, "date": "2026-01-02"}
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/users/measurements", token, waist, nil)
+		waist["value"] = 82
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/users/measurements", token, waist, nil)
+
+		inches := map[string]any{"metric": "waist", "value": 40, "unit": "in", "date": "2026-01-03"}
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/users/measurements", token, inches, nil)
+
+		var measurements []testMeasurement
+		path := "/users/measurements?metric=waist&since=2026-01-01%2000:00:00&until=2026-01-04%2000:00:00"
+		ts.expect(t, http.StatusOK, http.MethodGet, path, token, nil, &measurements)
+		if len(measurements) != 2 || measurements[0].Value != 82 || measurements[1].Value != 101.6 {
+			t.Fatalf("got measurements %+v", measurements)
+		}
+
+		bad := []map[string]any{
+			{"metric": "neck", "value": 40},
+			{"metric": "waist", "value": 0},
+			{"metric": "waist", "value": 80, "unit": "kg"},
+			{"metric": "resting_heart_rate", "value": 60, "unit": "cm"},
+			{"metric": "body_fat", "value": 2.5},
+			{"metric": "body_fat", "value": 70.5},
+		}
+		for _, body := range bad {
+			ts.expect(t, http.StatusBadRequest, http.MethodPost, "/users/measurements", token, body, nil)
+		}
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, "/users/measurements?metric=neck", token, nil, nil)
+	})
+}
@@ -2,22 +2,31 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/stanislavCasciuc/atom-fit/api/response"
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/nutrients"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
 //	@GetMacronutrientsGoalPerDayHandler	godoc
 //	@Summary							Get macronutrients goal per day
-//	@Description						Get macronutrients goal per day for the user
+//	@Description						Get macronutrients goal per day for the user with the BMR, TDEE and calories adjustment it is computed from. The adaptive part suggests a weekly calories adjustment from the weight trend and the logged meals
 //	@Tags								nutrients
 //	@Accept								json
 //	@Produce							json
+//	@Param								units	query	string	false	"Units override, metric or imperial"
 //	@Security							ApiKeyAuth
-//	@Success							200	{object}	nutrients.UserNutrientsGoal
+//	@Success							200	{object}	nutrients.Calculation
 //	@Router								/nutrients/daily-goal [get]
 func (h *Handlers) GetMacronutrientsGoalPerDayHandler(w http.ResponseWriter, r *http.Request) {
 	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
 
 	userAttr, err := h.store.Users.GetUserAttr(r.Context(), u.ID)
 	if err != nil {
@@ -25,10 +34,177 @@ func (h *Handlers) GetMacronutrientsGoalPerDayHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	goal := nutrients.CalculateMacronutrients(*userAttr)
+	now := time.Now()
+	if err := h.loadBodyFat(r.Context(), userAttr, now); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	goal := nutrients.Calculate(*userAttr)
+
+	start, end := nutrients.AdaptiveWindowAt(now)
+	weights, err := h.store.Users.GetUserWeightRange(r.Context(), u.ID, start.Add(-nutrients.AdaptiveWarmup), end)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	intake, err := h.store.Meals.GetDailyCalories(r.Context(), u.ID, start, end)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	adaptive := nutrients.Adapt(*userAttr, goal, weights, intake, now)
+	goal.Adaptive = &adaptive
+	units.Calculation(&goal, system)
 
 	if err := response.WriteJSON(w, http.StatusOK, goal); err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
 }
+
+type LogMealPayload struct {
+	FoodID int64   `json:"food_id" validate:"required"`
+	Meal   string  `json:"meal"    validate:"required,oneof=breakfast lunch dinner snack"`
+	Grams  float32 `json:"grams"   validate:"gt=0,lte=5000"`
+	// Date is formatted as 2006-01-02, today when empty
+	Date string `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+//	@LogMeal		godoc
+//	@Summary		Log a meal
+//	@Description	Log grams of a food eaten at a meal
+//	@Tags			nutrients
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		LogMealPayload	true	"Meal payload"
+//	@Success		201		{object}	store.Meal
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/nutrients/meals [post]
+func (h *Handlers) LogMealHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	var payload LogMealPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	date := time.Now()
+	if payload.Date != "" {
+		date, _ = time.Parse(time.DateOnly, payload.Date)
+	}
+
+	m := &store.Meal{
+		UserID: u.ID,
+		FoodID: payload.FoodID,
+		Meal:   payload.Meal,
+		Grams:  payload.Grams,
+		Date:   date,
+	}
+	if err := h.store.Meals.Create(r.Context(), m); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusCreated, m); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@DeleteMeal		godoc
+//	@Summary		Delete a meal
+//	@Description	Delete a logged meal of the current user
+//	@Tags			nutrients
+//	@Param			mealID	path	int	true	"Meal ID"
+//	@Success		204		"No Content"
+//	@Failure		404		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/nutrients/meals/{mealID} [delete]
+func (h *Handlers) DeleteMealHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	id, err := idParam(r, "mealID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	h.writeNoContentOrError(w, r, h.store.Meals.Delete(r.Context(), id, u.ID))
+}
+
+type DailyNutrientsResponse struct {
+	Date      string                      `json:"date"`
+	Goal      nutrients.UserNutrientsGoal `json:"goal"`
+	Consumed  nutrients.UserNutrientsGoal `json:"consumed"`
+	Remaining nutrients.UserNutrientsGoal `json:"remaining"`
+	Meals     []store.Meal                `json:"meals"`
+}
+
+//	@GetDailyNutrients	godoc
+//	@Summary			Get daily nutrients
+//	@Description		Get the nutrients consumed on a date against the goal computed from the weight current on that date
+//	@Tags				nutrients
+//	@Produce			json
+//	@Param				date	query		string	false	"Date, 2006-01-02, today by default"
+//	@Success			200		{object}	DailyNutrientsResponse
+//	@Failure			400		{object}	error
+//	@Failure			404		{object}	error
+//	@Security			ApiKeyAuth
+//	@Router				/nutrients/daily [get]
+func (h *Handlers) GetDailyNutrientsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	date := time.Now()
+	if d := r.URL.Query().Get("date"); d != "" {
+		var err error
+		date, err = time.Parse(time.DateOnly, d)
+		if err != nil {
+			h.resp.BadRequestError(w, r, err)
+			return
+		}
+	}
+
+	userAttr, err := h.store.Users.GetUserAttrAt(r.Context(), u.ID, date)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := h.loadBodyFat(r.Context(), userAttr, date); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	meals, err := h.store.Meals.GetByDate(r.Context(), u.ID, date)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	goal := nutrients.CalculateMacronutrients(*userAttr)
+	consumed := nutrients.Consumed(meals)
+	resp := DailyNutrientsResponse{
+		Date:      date.Format(time.DateOnly),
+		Goal:      goal,
+		Consumed:  consumed,
+		Remaining: goal.Sub(consumed),
+		Meals:     meals,
+	}
+	if err := response.WriteJSON(w, http.StatusOK, resp); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,73 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testMacros struct {
+	Calories      float32 `json:"calories"`
+	Proteins      float32 `json:"proteins"`
+	Fats          float32 `json:"fats"`
+	Carbohydrates float32 `json:"carbohydrates"`
+}
+
+func TestDailyGoal(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		var goal map[string]any
+		ts.expect(t, http.StatusOK, http.MethodGet, "/nutrients/daily-goal", token, nil, &goal)
+		if len(goal) == 0 {
+			t.Fatal("got an empty daily goal")
+		}
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, "/nutrients/daily-goal?units=stone", token, nil, nil)
+	})
+}
+
+func TestMeals(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		var food struct {
+			ID int64 `json:"id"`
+		}
+		body := map[string]any{
+			"name":          "oats",
+			"barcode":       "0123",
+			"calories":      400,
+			"proteins":      10,
+			"fats":          10,
+			"carbohydrates": 60,
+		}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/foods", token, body, &food)
+		ts.expect(t, http.StatusConflict, http.MethodPost, "/foods", token, body, nil)
+
+		var meal struct {
+			ID int64 `json:"id"`
+		}
+		logMeal := map[string]any{"food_id": food.ID, "meal": "breakfast", "grams": 50, "date": "2026-01-02"}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/nutrients/meals", token, logMeal, &meal)
+
+		unknown := map[string]any{"food_id": food.ID + 1, "meal": "lunch", "grams": 50}
+		ts.expect(t, http.StatusNotFound, http.MethodPost, "/nutrients/meals", token, unknown, nil)
+
+		var daily struct {
+			Date     string     `json:"date"`
+			Consumed testMacros `json:"consumed"`
+			Meals    []any      `json:"meals"`
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/nutrients/daily?date=2026-01-02", token, nil, &daily)
+		if daily.Date != "2026-01-02" || len(daily.Meals) != 1 || daily.Consumed.Calories != 200 {
+			t.Fatalf("got daily nutrients %+v", daily)
+		}
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, "/nutrients/daily?date=yesterday", token, nil, nil)
+
+		other := ts.login(t, "bobby")
+		path := fmt.Sprintf("/nutrients/meals/%d", meal.ID)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, other, nil, nil)
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, token, nil, nil)
+	})
+}
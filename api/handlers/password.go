@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+var resetExp = time.Duration(time.Hour)
+
+var ErrResetTokenNotFound = errors.New("reset code is invalid or expired")
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPasswordHandler godoc
+//
+//	@Summary		Request a password reset
+//	@Description	Email a one-time password reset code if a user with the email exists
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ForgotPasswordPayload	true	"Forgot Password Payload"
+//	@Success		200		{object}	response.SuccessResponse
+//	@Router			/auth/forgot-password [post]
+func (h *Handlers) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ForgotPasswordPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	u, err := h.store.Users.GetByEmail(ctx, payload.Email)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			// do not reveal which emails are registered
+			if err := response.WriteSuccess(w); err != nil {
+				h.resp.InternalServerError(w, r, err)
+			}
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	plainToken, hashToken := newInviteToken()
+	if err := h.store.Users.CreatePasswordReset(ctx, u.ID, hashToken, resetExp); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	h.workers.Go("password reset email", func(context.Context) { h.sendResetPassword(u, plainToken) })
+
+	if err := response.WriteSuccess(w); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token"    validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// ResetPasswordHandler godoc
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with the emailed reset code, previously issued tokens stop working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ResetPasswordPayload	true	"Reset Password Payload"
+//	@Success		200		{object}	response.SuccessResponse
+//	@Failure		404		{object}	error
+//	@Router			/auth/reset-password [post]
+func (h *Handlers) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload ResetPasswordPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	var u store.User
+	if err := u.Password.Set(payload.Password); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.store.Users.ResetPassword(r.Context(), payload.Token, u.Password.Hash); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, ErrResetTokenNotFound)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteSuccess(w); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handlers) sendResetPassword(u *store.User, plainToken string) {
+	err := mailer.SendResetPassword(h.mailer, u.Username, u.Email, plainToken, resetExp)
+	if err != nil {
+		h.log.Errorw("cannot send password reset email", "user_id", u.ID, "error", err)
+	}
+}
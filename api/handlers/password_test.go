@@ -0,0 +1,31 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResetPassword(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.login(t, "alice")
+
+		unknown := map[string]string{"email": "nobody@example.com"}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/forgot-password", "", unknown, nil)
+
+		body := map[string]string{"email": "alice@example.com"}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/forgot-password", "", body, nil)
+		code := ts.code(t, "alice@example.com", "Password Reset")
+
+		reset := map[string]string{"token": code, "password": "new-password"}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/reset-password", "", reset, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodPost, "/auth/reset-password", "", reset, nil)
+
+		login := map[string]string{"email": "alice@example.com", "password": "password123"}
+		ts.expect(t, http.StatusUnauthorized, http.MethodPost, "/auth/login", "", login, nil)
+
+		var tokens testTokens
+		login["password"] = "new-password"
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/login", "", login, &tokens)
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", tokens.Token, nil, nil)
+	})
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+var (
+	ErrCurrentPasswordRequired = errors.New("the current password is required to change the email or password")
+	ErrWrongPassword           = errors.New("the current password is incorrect")
+	ErrEmailChangeNotFound     = errors.New("email confirmation code is invalid or expired")
+)
+
+// UpdateProfilePayload changes the profile of the current user. Changing
+// the email or the password requires the current password.
+type UpdateProfilePayload struct {
+	Username        *string                `json:"username"         validate:"omitnil,min=4,max=20"`
+	Email           *string                `json:"email"            validate:"omitnil,email"`
+	CurrentPassword *string                `json:"current_password"`
+	Password        *string                `json:"password"         validate:"omitnil,min=8"`
+	Units           *string                `json:"units"            validate:"omitnil,oneof=metric imperial"`
+	UserAttr        *UpdateUserAttrPayload `json:"user_attr"        validate:"omitnil"`
+}
+
+// ProfileResponse is the updated user. PendingEmail is set until the new
+// email is confirmed and Tokens replace the revoked ones after a password
+// change.
+type ProfileResponse struct {
+	*store.User
+	PendingEmail string         `json:"pending_email,omitempty"`
+	Tokens       *TokenResponse `json:"tokens,omitempty"`
+}
+
+//	@GetMe			godoc
+//	@Summary		Get profile
+//	@Description	Get the current user with its attributes
+//	@Tags			users
+//	@Produce		json
+//	@Param			units	query		string	false	"Units override, metric or imperial"
+//	@Success		200		{object}	store.User
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [get]
+func (h *Handlers) GetMeHandler(w http.ResponseWriter, r *http.Request) {
+	h.GetUserWithAttrHandler(w, r)
+}
+
+//	@UpdateMe		godoc
+//	@Summary		Update profile
+//	@Description	Update the username, email, password and attributes of the current user. A new email is only set once the code sent to it is confirmed, a password change revokes all sessions and returns new tokens
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		UpdateProfilePayload	true	"Profile payload"
+//	@Param			units	query		string					false	"Units override, metric or imperial"
+//	@Success		200		{object}	ProfileResponse
+//	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		409		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me [patch]
+func (h *Handlers) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	ctx := r.Context()
+
+	var payload UpdateProfilePayload
+	if err := response.ReadJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	// the attributes are read in the new units when they change too
+	if r.URL.Query().Get("units") == "" && payload.Units != nil && units.Valid(*payload.Units) {
+		system = *payload.Units
+	}
+	if payload.UserAttr != nil {
+		payload.UserAttr.toMetric(system)
+	}
+
+	if err := response.Validate.Struct(payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	emailChanged := payload.Email != nil && *payload.Email != u.Email
+	if emailChanged || payload.Password != nil {
+		if payload.CurrentPassword == nil {
+			h.resp.BadRequestError(w, r, ErrCurrentPasswordRequired)
+			return
+		}
+		err := bcrypt.CompareHashAndPassword(u.Password.Hash, []byte(*payload.CurrentPassword))
+		if err != nil {
+			h.resp.ForbiddenError(w, r, ErrWrongPassword)
+			return
+		}
+	}
+
+	ua, err := h.store.Users.GetUserAttr(ctx, u.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	if payload.UserAttr != nil {
+		if err := payload.UserAttr.apply(ua); err != nil {
+			h.resp.BadRequestError(w, r, err)
+			return
+		}
+		if err := h.checkBodyFat(ctx, ua); err != nil {
+			switch err {
+			case ErrBodyFatRequired:
+				h.resp.BadRequestError(w, r, err)
+			default:
+				h.resp.InternalServerError(w, r, err)
+			}
+			return
+		}
+	}
+
+	if emailChanged {
+		switch _, err := h.store.Users.GetByEmail(ctx, *payload.Email); err {
+		case store.ErrNotFound:
+		case nil:
+			h.resp.ConflictError(w, r, store.ErrDuplicateEmail)
+			return
+		default:
+			h.resp.InternalServerError(w, r, err)
+			return
+		}
+	}
+
+	var update store.ProfileUpdate
+	if payload.Username != nil && *payload.Username != u.Username {
+		update.Username = payload.Username
+	}
+	if payload.Units != nil && *payload.Units != u.Units {
+		update.Units = payload.Units
+	}
+	if payload.UserAttr != nil {
+		update.UserAttr = ua
+	}
+	if payload.Password != nil {
+		if err := u.Password.Set(*payload.Password); err != nil {
+			h.resp.InternalServerError(w, r, err)
+			return
+		}
+		update.PasswordHash = u.Password.Hash
+	}
+	var plainToken string
+	if emailChanged {
+		var hashToken string
+		plainToken, hashToken = newInviteToken()
+		update.EmailChange = &store.EmailChange{Email: *payload.Email, Token: hashToken, Exp: exp}
+	}
+
+	if err := h.store.Users.UpdateProfile(ctx, u.ID, update); err != nil {
+		switch err {
+		case store.ErrDuplicateUsername:
+			h.resp.ConflictError(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+	if update.Username != nil {
+		u.Username = *update.Username
+	}
+	if update.Units != nil {
+		u.Units = *update.Units
+	}
+	units.UserAttributes(ua, system)
+	u.UserAttr = *ua
+
+	resp := ProfileResponse{User: u}
+	if emailChanged {
+		pending := &store.User{ID: u.ID, Username: u.Username, Email: *payload.Email}
+		h.workers.Go("email change confirmation", func(context.Context) { h.sendConfirmEmail(pending, plainToken) })
+		resp.PendingEmail = *payload.Email
+	}
+
+	if payload.Password != nil {
+		tokens, err := h.issueTokens(ctx, u)
+		if err != nil {
+			h.resp.InternalServerError(w, r, err)
+			return
+		}
+		resp.Tokens = &tokens
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, resp); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// ConfirmEmailHandler godoc
+//
+//	@Summary		Confirm email change
+//	@Description	Set the new email of a user by the code sent to it
+//	@Tags			users
+//	@Param			token	path	string	true	"Confirmation code"
+//	@Success		204		"No Content"
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Router			/users/confirm-email/{token} [put]
+func (h *Handlers) ConfirmEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	if err := h.store.Users.ConfirmEmailChange(r.Context(), token); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, ErrEmailChangeNotFound)
+		case store.ErrDuplicateEmail:
+			h.resp.ConflictError(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) sendConfirmEmail(u *store.User, plainToken string) {
+	if err := mailer.SendConfirmEmail(h.mailer, u.Username, u.Email, plainToken, exp); err != nil {
+		h.log.Errorw("cannot send email change confirmation", "user_id", u.ID, "error", err)
+	}
+}
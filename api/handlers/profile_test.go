@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testProfile struct {
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	PendingEmail string `json:"pending_email"`
+}
+
+func TestUpdateUsername(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		ts.login(t, "bobby")
+
+		var profile testProfile
+		body := map[string]any{"username": "alicia"}
+		ts.expect(t, http.StatusOK, http.MethodPatch, "/users/me", token, body, &profile)
+		if profile.Username != "alicia" {
+			t.Fatalf("got profile %+v", profile)
+		}
+
+		body = map[string]any{"username": "bobby"}
+		ts.expect(t, http.StatusConflict, http.MethodPatch, "/users/me", token, body, nil)
+	})
+}
+
+func TestChangeEmail(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		ts.login(t, "bobby")
+
+		body := map[string]any{"email": "alicia@example.com"}
+		ts.expect(t, http.StatusBadRequest, http.MethodPatch, "/users/me", token, body, nil)
+		body["current_password"] = "wrong-password"
+		ts.expect(t, http.StatusForbidden, http.MethodPatch, "/users/me", token, body, nil)
+
+		body["current_password"] = "password123"
+		body["email"] = "bobby@example.com"
+		ts.expect(t, http.StatusConflict, http.MethodPatch, "/users/me", token, body, nil)
+
+		var profile testProfile
+		body["email"] = "alicia@example.com"
+		ts.expect(t, http.StatusOK, http.MethodPatch, "/users/me", token, body, &profile)
+		if profile.Email != "alice@example.com" || profile.PendingEmail != "alicia@example.com" {
+			t.Fatalf("got profile %+v", profile)
+		}
+		code := ts.code(t, "alicia@example.com", "Email Change Confirmation")
+
+		ts.expect(t, http.StatusNoContent, http.MethodPut, "/users/confirm-email/"+code, "", nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodPut, "/users/confirm-email/"+code, "", nil, nil)
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", token, nil, &profile)
+		if profile.Email != "alicia@example.com" {
+			t.Fatalf("got profile %+v", profile)
+		}
+	})
+}
+
+func TestConfirmTakenEmail(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		body := map[string]any{"email": "bobby@example.com", "current_password": "password123"}
+		ts.expect(t, http.StatusOK, http.MethodPatch, "/users/me", token, body, nil)
+		code := ts.code(t, "bobby@example.com", "Email Change Confirmation")
+		ts.login(t, "bobby")
+
+		ts.expect(t, http.StatusConflict, http.MethodPut, "/users/confirm-email/"+code, "", nil, nil)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+
+		var profile struct {
+			Tokens *testTokens `json:"tokens"`
+		}
+		body := map[string]any{"password": "new-password", "current_password": "password123"}
+		ts.expect(t, http.StatusOK, http.MethodPatch, "/users/me", token, body, &profile)
+		if profile.Tokens == nil {
+			t.Fatal("got no new tokens")
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", profile.Tokens.Token, nil, nil)
+
+		login := map[string]string{"email": "alice@example.com", "password": "new-password"}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/login", "", login, nil)
+	})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+//	@GetPersonalRecords	godoc
+//	@Summary			Get personal records
+//	@Description		Get the personal records of the current user for every exercise they logged
+//	@Tags				records
+//	@Produce			json
+//	@Param				units	query		string	false	"Units override, metric or imperial"
+//	@Success			200		{object}	[]store.PersonalRecord
+//	@Security			ApiKeyAuth
+//	@Router				/users/me/records [get]
+func (h *Handlers) GetPersonalRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	records, err := h.store.PersonalRecords.GetByUserID(r.Context(), u.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	units.Records(records, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, records); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+//	@GetExerciseProgress	godoc
+//	@Summary				Get exercise progress
+//	@Description			Get the progress of the current user on an exercise, one point per finished workout
+//	@Tags					records
+//	@Produce				json
+//	@Param					exerciseID	path		int		true	"Exercise ID"
+//	@Param					limit		query		int		false	"Limit"
+//	@Param					offset		query		int		false	"Offset"
+//	@Param					sort		query		string	false	"Sort"
+//	@Param					since		query		string	false	"Since, 2006-01-02 15:04:05"
+//	@Param					until		query		string	false	"Until, 2006-01-02 15:04:05"
+//	@Param					units		query		string	false	"Units override, metric or imperial"
+//	@Success				200			{object}	[]store.ExerciseProgress
+//	@Failure				400			{object}	error
+//	@Failure				404			{object}	error
+//	@Security				ApiKeyAuth
+//	@Router					/exercises/{exerciseID}/progress [get]
+func (h *Handlers) GetExerciseProgressHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	id, err := idParam(r, "exerciseID")
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	fq := pagination.PaginatedQuery{
+		Limit:  20,
+		Offset: 0,
+		Sort:   "asc",
+	}
+
+	fq, err = fq.Parse(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(fq); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	// Parse drops malformed bounds, which would silently return every point
+	for _, name := range []string{"since", "until"} {
+		if _, err := timeQuery(r, name, time.Time{}); err != nil {
+			h.resp.BadRequestError(w, r, err)
+			return
+		}
+	}
+
+	if _, err := h.store.Exercises.GetByID(r.Context(), id); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	progress, err := h.store.PersonalRecords.GetProgress(r.Context(), fq, u.ID, id)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	units.Progress(progress, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, progress); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExerciseProgress(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		id, exerciseID := ts.createWorkout(t, token, "legs day")
+
+		start := map[string]any{"workout_id": id}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active", token, start, nil)
+		set := map[string]any{"exercise_id": exerciseID, "reps": 1, "weight": 100}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active/sets", token, set, nil)
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active/finish", token, nil, nil)
+
+		path := fmt.Sprintf("/exercises/%d/progress", exerciseID)
+		var progress []struct {
+			MaxWeight    float32 `json:"max_weight"`
+			Estimated1RM float32 `json:"estimated_1rm"`
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, path, token, nil, &progress)
+		if len(progress) != 1 || progress[0].MaxWeight != 100 || progress[0].Estimated1RM != 100 {
+			t.Fatalf("got progress %+v", progress)
+		}
+
+		ts.expect(t, http.StatusOK, http.MethodGet, path+"?since=2999-01-01%2000:00:00", token, nil, &progress)
+		if len(progress) != 0 {
+			t.Fatalf("got progress %+v in the future", progress)
+		}
+
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, path+"?since=yesterday", token, nil, nil)
+		ts.expect(t, http.StatusBadRequest, http.MethodGet, path+"?until=2026-01-02", token, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodGet, fmt.Sprintf("/exercises/%d/progress", exerciseID+1), token, nil, nil)
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
+var ErrReviewConflict = errors.New("user already reviewed this workout")
+
 type WorkoutReviewPayload struct {
 	Title   string `json:"title"   validate:"required,max=255"`
 	Rating  int    `json:"rating"  validate:"required,oneof=1 2 3 4 5"`
@@ -26,6 +29,7 @@ type WorkoutReviewPayload struct {
 //	@Param			payload		body		WorkoutReviewPayload	true	"Review workout payload"
 //	@Success		200			{object}	store.WorkoutReview
 //	@Failure		400			{object}	error
+//	@Failure		409			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/reviews/workout/{workoutID} [post]
 func (h *Handlers) ReviewWorkoutHandler(w http.ResponseWriter, r *http.Request) {
@@ -51,7 +55,14 @@ func (h *Handlers) ReviewWorkoutHandler(w http.ResponseWriter, r *http.Request)
 	}
 	err = h.store.Reviews.CreateWorkout(r.Context(), wr)
 	if err != nil {
-		h.resp.InternalServerError(w, r, err)
+		switch err {
+		case store.ErrConflict:
+			h.resp.ConflictError(w, r, ErrReviewConflict)
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
 		return
 	}
 
@@ -93,30 +104,83 @@ func (h *Handlers) GetWorkoutReviewsHandler(w http.ResponseWriter, r *http.Reque
 
 //	@PatchWorkoutReview	godoc
 //	@Summary			Update workout review
-//	@Description		Update workout review
+//	@Description		Update the workout review of the current user
 //	@Tags				reviews
 //	@Accept				json
 //	@Produce			json
 //	@Param				workoutID	path		int						true	"Workout ID"
-//	@Param				reviewID	path		int						true	"Review ID"
 //	@Param				payload		body		WorkoutReviewPayload	true	"Review workout payload"
 //	@Success			200			{object}	store.WorkoutReview
 //	@Failure			400			{object}	error
+//	@Failure			404			{object}	error
 //	@Security			ApiKeyAuth
-//	@Router				/reviews/workout/{workoutID}/{reviewID} [patch]
+//	@Router				/reviews/workout/{workoutID} [patch]
 func (h *Handlers) PatchWorkoutReviewHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	idString := chi.URLParam(r, "workoutID")
+	workoutID, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	var payload WorkoutReviewPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	wr := &store.WorkoutReview{
+		WorkoutID: workoutID,
+		Title:     payload.Title,
+		Content:   payload.Content,
+		Rating:    payload.Rating,
+		UserID:    u.ID,
+	}
+	if err := h.store.Reviews.Update(r.Context(), wr); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := response.WriteJSON(w, http.StatusOK, wr); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
 }
 
 //	@DeleteWorkoutReview	godoc
 //	@Summary				Delete workout review
-//	@Description			Delete workout review
+//	@Description			Delete the workout review of the current user
 //	@Tags					reviews
 //	@Accept					json
 //	@Produce				json
-//	@Param					workoutID	path		int	true	"Workout ID"
-//	@Param					reviewID	path		int	true	"Review ID"
-//	@Success				204			{object}	store.WorkoutReview
+//	@Param					workoutID	path	int	true	"Workout ID"
+//	@Success				204			"No Content"
+//	@Failure				404			{object}	error
 //	@Security				ApiKeyAuth
-//	@Router					/reviews/workout/{workoutID}/{reviewID} [delete]
+//	@Router					/reviews/workout/{workoutID} [delete]
 func (h *Handlers) DeleteWorkoutReviewHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	idString := chi.URLParam(r, "workoutID")
+	workoutID, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.Reviews.Delete(r.Context(), u.ID, workoutID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
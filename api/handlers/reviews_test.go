@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestReviews(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		owner := ts.login(t, "alice")
+		token := ts.login(t, "bobby")
+		id, _ := ts.createWorkout(t, owner, "legs day")
+		path := fmt.Sprintf("/reviews/workout/%d", id)
+
+		review := map[string]any{"title": "great", "rating": 5, "content": "hard but fun"}
+		ts.expect(t, http.StatusNotFound, http.MethodPatch, path, token, review, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, token, nil, nil)
+
+		ts.expect(t, http.StatusOK, http.MethodPost, path, token, review, nil)
+		ts.expect(t, http.StatusConflict, http.MethodPost, path, token, review, nil)
+		unknown := fmt.Sprintf("/reviews/workout/%d", id+1)
+		ts.expect(t, http.StatusNotFound, http.MethodPost, unknown, token, review, nil)
+
+		review["rating"] = 4
+		ts.expect(t, http.StatusOK, http.MethodPatch, path, token, review, nil)
+
+		var reviews []struct {
+			Rating int `json:"rating"`
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, path, "", nil, &reviews)
+		if len(reviews) != 1 || reviews[0].Rating != 4 {
+			t.Fatalf("got reviews %+v", reviews)
+		}
+
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, token, nil, nil)
+	})
+}
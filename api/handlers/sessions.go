@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/stanislavCasciuc/atom-fit/api/middleware"
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid, expired or revoked")
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshHandler godoc
+//
+//	@Summary		Refresh tokens
+//	@Description	Exchange a refresh token for a new access token and a new refresh token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		RefreshPayload	true	"Refresh Payload"
+//	@Success		200		{object}	TokenResponse
+//	@Failure		401		{object}	error
+//	@Router			/auth/refresh [post]
+func (h *Handlers) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshPayload
+	if err := h.resp.ReadAndValidateJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	plainToken, hashToken, err := newRefreshToken()
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	session, err := h.store.Sessions.Rotate(
+		ctx,
+		hashRefreshToken(payload.RefreshToken),
+		hashToken,
+		time.Now().Add(h.config.Auth.RefreshExp),
+	)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.UnauthorizedError(w, r, ErrInvalidRefreshToken)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	u, err := h.store.Users.GetByID(ctx, session.UserID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	if !u.IsActive {
+		h.resp.ForbiddenError(w, r, ErrUserNotActive)
+		return
+	}
+
+	token, err := h.generateAccessToken(u, session.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	res := TokenResponse{
+		Token:        token,
+		RefreshToken: plainToken,
+	}
+	if err := response.WriteJSON(w, http.StatusOK, res); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// LogoutHandler godoc
+//
+//	@Summary		Logout
+//	@Description	Revoke the session of the current access token and its refresh token
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204	"No Content"
+//	@Security		ApiKeyAuth
+//	@Router			/auth/logout [post]
+func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	session := h.GetSessionFromCtx(r)
+	if err := h.store.Sessions.Revoke(r.Context(), session.ID); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAllHandler godoc
+//
+//	@Summary		Logout from all devices
+//	@Description	Revoke all sessions of the current user
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204	"No Content"
+//	@Security		ApiKeyAuth
+//	@Router			/auth/logout-all [post]
+func (h *Handlers) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	if err := h.store.Sessions.RevokeAll(r.Context(), u.ID); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) GetSessionFromCtx(r *http.Request) *store.Session {
+	ctx := r.Context()
+	s, _ := ctx.Value(middleware.SessionCtx).(*store.Session)
+	return s
+}
+
+// issueTokens starts a new session for the user and returns its access and
+// refresh tokens.
+func (h *Handlers) issueTokens(ctx context.Context, u *store.User) (TokenResponse, error) {
+	plainToken, hashToken, err := newRefreshToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	session := &store.Session{
+		ID:           uuid.New().String(),
+		UserID:       u.ID,
+		RefreshToken: hashToken,
+		Exp:          time.Now().Add(h.config.Auth.RefreshExp),
+	}
+	if err := h.store.Sessions.Create(ctx, session); err != nil {
+		return TokenResponse{}, err
+	}
+
+	token, err := h.generateAccessToken(u, session.ID)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		Token:        token,
+		RefreshToken: plainToken,
+	}, nil
+}
+
+func (h *Handlers) generateAccessToken(u *store.User, sessionID string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  u.ID,
+		"jti":  sessionID,
+		"role": u.Role,
+		"exp":  now.Add(h.config.Auth.Iat).Unix(),
+		"iat":  now.Unix(),
+		"nbf":  now.Unix(),
+		"iss":  h.config.Auth.Aud,
+		"aud":  h.config.Auth.Aud,
+	}
+
+	return h.authenticator.GenerateToken(claims)
+}
+
+// newRefreshToken returns a random plain refresh token for the client and its
+// hash for storage.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	plainToken := base64.RawURLEncoding.EncodeToString(b)
+	return plainToken, hashRefreshToken(plainToken), nil
+}
+
+func hashRefreshToken(plainToken string) string {
+	hash := sha256.Sum256([]byte(plainToken))
+	return hex.EncodeToString(hash[:])
+}
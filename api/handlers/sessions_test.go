@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (ts *testServer) loginTokens(t *testing.T, name string) testTokens {
+	t.Helper()
+
+	var tokens testTokens
+	body := map[string]string{"email": name + "@example.com", "password": "password123"}
+	ts.expect(t, http.StatusOK, http.MethodPost, "/auth/login", "", body, &tokens)
+
+	return tokens
+}
+
+func TestRefresh(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.login(t, "alice")
+		tokens := ts.loginTokens(t, "alice")
+
+		var refreshed testTokens
+		body := map[string]string{"refresh_token": tokens.RefreshToken}
+		ts.expect(t, http.StatusOK, http.MethodPost, "/auth/refresh", "", body, &refreshed)
+		if refreshed.RefreshToken == "" || refreshed.RefreshToken == tokens.RefreshToken {
+			t.Fatalf("got refreshed tokens %+v", refreshed)
+		}
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", refreshed.Token, nil, nil)
+
+		ts.expect(t, http.StatusUnauthorized, http.MethodPost, "/auth/refresh", "", body, nil)
+		unknown := map[string]string{"refresh_token": "unknown"}
+		ts.expect(t, http.StatusUnauthorized, http.MethodPost, "/auth/refresh", "", unknown, nil)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		ts.login(t, "alice")
+		tokens := ts.loginTokens(t, "alice")
+		other := ts.loginTokens(t, "alice")
+
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/auth/logout", tokens.Token, nil, nil)
+		ts.expect(t, http.StatusUnauthorized, http.MethodGet, "/users/me", tokens.Token, nil, nil)
+		body := map[string]string{"refresh_token": tokens.RefreshToken}
+		ts.expect(t, http.StatusUnauthorized, http.MethodPost, "/auth/refresh", "", body, nil)
+		ts.expect(t, http.StatusOK, http.MethodGet, "/users/me", other.Token, nil, nil)
+
+		ts.expect(t, http.StatusNoContent, http.MethodPost, "/auth/logout-all", other.Token, nil, nil)
+		ts.expect(t, http.StatusUnauthorized, http.MethodGet, "/users/me", other.Token, nil, nil)
+	})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/api/response"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+)
+
+// defaultStatsWindow is used when the query has no since.
+const defaultStatsWindow = 12 * 7 * 24 * time.Hour
+
+var ErrInvalidWindow = errors.New("since must be before until")
+
+//	@GetStats		godoc
+//	@Summary		Get training stats
+//	@Description	Get the training stats of the current user between since and until, by default the last 12 weeks
+//	@Tags			stats
+//	@Produce		json
+//	@Param			since	query		string	false	"Since, 2006-01-02 15:04:05"
+//	@Param			until	query		string	false	"Until, 2006-01-02 15:04:05"
+//	@Param			units	query		string	false	"Units override, metric or imperial"
+//	@Success		200		{object}	store.Stats
+//	@Failure		400		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/me/stats [get]
+func (h *Handlers) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	until, err := timeQuery(r, "until", time.Now().UTC())
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	since, err := timeQuery(r, "since", until.Add(-defaultStatsWindow))
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	if !since.Before(until) {
+		h.resp.BadRequestError(w, r, ErrInvalidWindow)
+		return
+	}
+
+	stats, err := h.store.Stats.Get(r.Context(), u.ID, since, until)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	units.Stats(stats, system)
+
+	if err := response.WriteJSON(w, http.StatusOK, stats); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// timeQuery parses the name query parameter formatted as time.DateTime,
+// returning def when it is not set.
+func timeQuery(r *http.Request, name string, def time.Time) (time.Time, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(time.DateTime, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be formatted as %s", name, time.DateTime)
+	}
+	return t, nil
+}
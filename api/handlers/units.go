@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
+)
+
+var ErrUnknownUnits = errors.New("units must be metric or imperial")
+
+// unitSystem returns the units query parameter when set, the preference of
+// the current user otherwise.
+func (h *Handlers) unitSystem(r *http.Request) (string, error) {
+	if system := r.URL.Query().Get("units"); system != "" {
+		if !units.Valid(system) {
+			return "", ErrUnknownUnits
+		}
+		return system, nil
+	}
+
+	if u := h.GetUserFromCtx(r); u != nil && u.Units != "" {
+		return u.Units, nil
+	}
+	return units.Metric, nil
+}
@@ -1,38 +1,48 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 
 	"github.com/stanislavCasciuc/atom-fit/api/middleware"
 	"github.com/stanislavCasciuc/atom-fit/api/response"
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/units"
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
-type ActivationPayload struct {
-	Token string `json:"token"`
-}
+var (
+	ErrInvitationNotFound = errors.New("verification code is invalid or expired")
+	ErrMacroRatiosSum     = errors.New("macro ratios must sum up to 1")
+	ErrBodyFatRequired    = errors.New("the katch_mcardle formula requires the body fat, set it or log a measurement")
+)
 
 // ActivateUser godoc
 //
-//	@Summary		Activate a ActivateUser
-//	@Description	Activate a user
+//	@Summary		Activate a user
+//	@Description	Activate a user by the verification code sent by email
 //	@Tags			users
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body	ActivationPayload	true	"Activate User Payload"
+//	@Param			token	path	string	true	"Verification code"
 //	@Success		204		"No Content"
-//	@Router			/users/activate [put]
+//	@Failure		404		{object}	error
+//	@Router			/users/activate/{token} [put]
 func (h *Handlers) ActivateUser(w http.ResponseWriter, r *http.Request) {
-	var payload ActivationPayload
-	if err := response.ReadJSON(w, r, &payload); err != nil {
-		h.resp.BadRequestError(w, r, err)
-		return
-	}
+	token := chi.URLParam(r, "token")
 
-	err := h.store.Users.Activate(r.Context(), payload.Token)
+	err := h.store.Users.Activate(r.Context(), token)
 	if err != nil {
-		h.resp.InternalServerError(w, r, err)
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, ErrInvitationNotFound)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
 		return
 	}
 
@@ -68,17 +78,25 @@ func (h *Handlers) GetUserFromCtx(r *http.Request) *store.User {
 // @Tags					users
 // @Accept					json
 // @Produce				json
-// @Success				200	{object}	store.User
+// @Param					units	query		string	false	"Units override, metric or imperial"
+// @Success				200		{object}	store.User
 // @Security				ApiKeyAuth
 // @Router					/users/attributes [get]
 func (h *Handlers) GetUserWithAttrHandler(w http.ResponseWriter, r *http.Request) {
 	u := h.GetUserFromCtx(r)
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
 	ua, err := h.store.Users.GetUserAttr(r.Context(), u.ID)
 	if err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
 
+	units.UserAttributes(ua, system)
 	u.UserAttr = *ua
 
 	if err := response.WriteJSON(w, http.StatusOK, u); err != nil {
@@ -99,6 +117,7 @@ type LogWeightPayload struct {
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body	LogWeightPayload	true	"Log Weight Payload"
+//	@Param			units	query	string				false	"Units override, metric or imperial"
 //	@Success		204		"No Content"
 //
 //	@Security		ApiKeyAuth
@@ -117,6 +136,14 @@ func (h *Handlers) LogWeightHandler(w http.ResponseWriter, r *http.Request) {
 		h.resp.BadRequestError(w, r, err)
 		return
 	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	payload.Weight = units.ToKg(payload.Weight, system)
+
 	if err := h.store.Users.AddUserWeight(r.Context(), u.ID, payload.Weight); err != nil {
 		if err == store.ErrConflict {
 			err = h.store.Users.UpdateUserWeight(r.Context(), u.ID, payload.Weight)
@@ -143,6 +170,7 @@ func (h *Handlers) LogWeightHandler(w http.ResponseWriter, r *http.Request) {
 // @Produce		json
 // @Param			limit	query		int	false	"Limit"
 // @Param			offset	query		int	false	"Offset"
+// @Param			units	query		string	false	"Units override, metric or imperial"
 // @Success		200		{object}	[]store.UserWeightByDate
 // @Security		ApiKeyAuth
 // @Router			/users/attributes/weight [get]
@@ -164,14 +192,173 @@ func (h *Handlers) GetUserWeight(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
 	uw, err := h.store.Users.GetUserWeight(r.Context(), fq, u.ID)
 	if err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
+	units.Weights(uw, system)
 
 	if err := response.WriteJSON(w, http.StatusOK, uw); err != nil {
 		h.resp.InternalServerError(w, r, err)
 		return
 	}
 }
+
+// UpdateUserAttrPayload changes the attributes, macro ratios that are all 0
+// reset the split to the default one. Height and weight goal are in the
+// unit system of the user, imperial heights are whole inches.
+type UpdateUserAttrPayload struct {
+	IsMale        *bool              `json:"is_male"`
+	Height        *int               `json:"height"         validate:"omitnil,max=250,min=100"`
+	Goal          *string            `json:"goal"           validate:"omitnil,oneof=lose gain maintain"`
+	WeightGoal    *float32           `json:"weight_goal"    validate:"omitnil,gt=0"`
+	BirthDate     *string            `json:"birth_date"     validate:"omitnil,datetime=2006-01-02"`
+	ActivityLevel *string            `json:"activity_level" validate:"omitnil,oneof=sedentary light moderate active very_active"`
+	Formula       *string            `json:"formula"        validate:"omitnil,oneof=mifflin harris_benedict katch_mcardle"`
+	BodyFat       *float32           `json:"body_fat"       validate:"omitnil,gte=3,lte=70"`
+	MacroRatios   *store.MacroRatios `json:"macro_ratios"   validate:"omitnil"`
+}
+
+// toMetric converts the height and weight goal from the system, it runs
+// before the validation so the limits are in cm and kg.
+func (p *UpdateUserAttrPayload) toMetric(system string) {
+	if p.Height != nil {
+		h := units.HeightToCm(*p.Height, system)
+		p.Height = &h
+	}
+	if p.WeightGoal != nil {
+		wg := units.ToKg(*p.WeightGoal, system)
+		p.WeightGoal = &wg
+	}
+}
+
+// apply sets the fields of the payload on the attributes.
+func (p UpdateUserAttrPayload) apply(ua *store.UserAttributes) error {
+	if p.IsMale != nil {
+		ua.IsMale = *p.IsMale
+	}
+	if p.Height != nil {
+		ua.Height = *p.Height
+	}
+	if p.Goal != nil {
+		ua.Goal = *p.Goal
+	}
+	if p.WeightGoal != nil {
+		ua.WeightGoal = *p.WeightGoal
+	}
+	if p.BirthDate != nil {
+		d, err := time.Parse(time.DateOnly, *p.BirthDate)
+		if err != nil {
+			return err
+		}
+		ua.BirthDate = &d
+		ua.Age = store.AgeAt(ua.BirthDate, time.Now())
+	}
+	if p.ActivityLevel != nil {
+		ua.ActivityLevel = *p.ActivityLevel
+	}
+	if p.Formula != nil {
+		ua.Formula = *p.Formula
+	}
+	if p.BodyFat != nil {
+		ua.BodyFat = p.BodyFat
+	}
+	if mr := p.MacroRatios; mr != nil {
+		switch sum := mr.Proteins + mr.Fats + mr.Carbohydrates; {
+		case sum == 0:
+			ua.MacroRatios = nil
+		case sum < 0.99 || sum > 1.01:
+			return ErrMacroRatiosSum
+		default:
+			ua.MacroRatios = mr
+		}
+	}
+
+	return nil
+}
+
+// checkBodyFat rejects the Katch-McArdle formula when the body fat is
+// neither set on the attributes nor measured.
+func (h *Handlers) checkBodyFat(ctx context.Context, ua *store.UserAttributes) error {
+	if ua.Formula != store.FormulaKatchMcArdle || ua.BodyFat != nil {
+		return nil
+	}
+
+	_, err := h.store.Measurements.GetLatestAt(ctx, ua.UserID, store.MetricBodyFat, time.Now())
+	if err == store.ErrNotFound {
+		return ErrBodyFatRequired
+	}
+	return err
+}
+
+//	@UpdateUserAttr	godoc
+//	@Summary		Update attributes
+//	@Description	Update the attributes of the current user, including the activity level, BMR formula, body fat and macro ratios used for the nutrients goal
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		UpdateUserAttrPayload	true	"Attributes payload"
+//	@Param			units	query		string					false	"Units override, metric or imperial"
+//	@Success		200		{object}	store.UserAttributes
+//	@Failure		400		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/users/attributes [patch]
+func (h *Handlers) UpdateUserAttrHandler(w http.ResponseWriter, r *http.Request) {
+	u := h.GetUserFromCtx(r)
+
+	var payload UpdateUserAttrPayload
+	if err := response.ReadJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	system, err := h.unitSystem(r)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	payload.toMetric(system)
+
+	if err := response.Validate.Struct(payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	ua, err := h.store.Users.GetUserAttr(r.Context(), u.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := payload.apply(ua); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+	if err := h.checkBodyFat(r.Context(), ua); err != nil {
+		switch err {
+		case ErrBodyFatRequired:
+			h.resp.BadRequestError(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := h.store.Users.UpdateUserAttr(r.Context(), ua); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+
+	units.UserAttributes(ua, system)
+	if err := response.WriteJSON(w, http.StatusOK, ua); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
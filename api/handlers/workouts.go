@@ -12,6 +12,8 @@ import (
 	"github.com/stanislavCasciuc/atom-fit/internal/store"
 )
 
+var ErrNotWorkoutOwner = errors.New("only the owner can modify this workout")
+
 type CreateWorkoutPayload struct {
 	Name             string                         `json:"name"          validate:"required"`
 	Description      string                         `json:"description"`
@@ -197,11 +199,16 @@ func (h *Handlers) GetWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	workout, err := h.store.Workouts.GetByID(ctx, id)
 	if err != nil {
-		h.resp.InternalServerError(w, r, err)
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
 		return
 	}
-	if workout == nil {
-		h.resp.NotFoundErorr(w, r, errors.New("resource not found"))
+	if workout.IsHidden {
+		h.resp.NotFoundErorr(w, r, store.ErrNotFound)
 		return
 	}
 
@@ -221,26 +228,143 @@ func (h *Handlers) GetWorkoutHandler(w http.ResponseWriter, r *http.Request) {
 
 //	@DeleteWorkout	godoc
 //	@Summary		Delete workout by ID
-//	@Description	Delete workout by ID
+//	@Description	Delete workout by ID, together with its likes, reviews and finished workouts
 //	@Tags			workouts
 //	@Accept			json
 //	@Produce		json
 //	@Param			workoutID	path	int	true	"Workout ID"
 //	@Success		204			"No Content"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/workouts/{workoutID} [delete]
 func (h *Handlers) DeleteWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	workout, ok := h.getOwnedWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Workouts.Delete(r.Context(), workout.ID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type UpdateWorkoutPayload struct {
+	Name             *string                        `json:"name"          validate:"omitnil,min=1,max=255"`
+	Description      *string                        `json:"description"`
+	TutorialLink     *string                        `json:"tutorial_link"`
+	ExercisesWorkout []CreateWorkoutExercisePayload `json:"exercises"     validate:"omitnil,min=1,dive"`
 }
 
 //	@PatchWorkout	godoc
 //	@Summary		Update workout by ID
-//	@Description	Update workout by ID
+//	@Description	Update workout details; when exercises are sent they replace the current ones in the given order
 //	@Tags			workouts
 //	@Accept			json
 //	@Produce		json
-//	@Param			workoutID	path	int	true	"Workout ID"
-//	@Success		204			"No Content"
+//	@Param			workoutID	path		int						true	"Workout ID"
+//	@Param			payload		body		UpdateWorkoutPayload	true	"Update Workout Payload"
+//	@Success		200			{object}	store.Workout
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
 //	@Security		ApiKeyAuth
 //	@Router			/workouts/{workoutID} [patch]
 func (h *Handlers) PatchWorkoutHandler(w http.ResponseWriter, r *http.Request) {
+	workout, ok := h.getOwnedWorkout(w, r)
+	if !ok {
+		return
+	}
+
+	var payload UpdateWorkoutPayload
+	if err := response.ReadJSON(w, r, &payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := response.Validate.Struct(payload); err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return
+	}
+
+	if payload.Name != nil {
+		workout.Name = *payload.Name
+	}
+	if payload.Description != nil {
+		workout.Description = *payload.Description
+	}
+	if payload.TutorialLink != nil {
+		workout.TutorialLink = *payload.TutorialLink
+	}
+	if payload.ExercisesWorkout != nil {
+		workout.WorkoutExercises = make([]store.WorkoutExercises, 0, len(payload.ExercisesWorkout))
+		for _, e := range payload.ExercisesWorkout {
+			workout.WorkoutExercises = append(workout.WorkoutExercises, store.WorkoutExercises{
+				ExerciseID: e.ExerciseID,
+				Duration:   e.Duration,
+			})
+		}
+	}
+
+	ctx := r.Context()
+	if err := h.store.Workouts.Update(ctx, workout); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	we, err := h.store.Workouts.GetWorkoutExercises(ctx, workout.ID)
+	if err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+	workout.WorkoutExercises = we
+
+	if err := response.WriteJSON(w, http.StatusOK, workout); err != nil {
+		h.resp.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// getOwnedWorkout loads the workout from the workoutID url param and checks
+// that it belongs to the user from context. On failure the error response is
+// already written and false is returned.
+func (h *Handlers) getOwnedWorkout(w http.ResponseWriter, r *http.Request) (*store.Workout, bool) {
+	u := h.GetUserFromCtx(r)
+	idString := chi.URLParam(r, "workoutID")
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		h.resp.BadRequestError(w, r, err)
+		return nil, false
+	}
+
+	workout, err := h.store.Workouts.GetByID(r.Context(), id)
+	if err != nil {
+		switch err {
+		case store.ErrNotFound:
+			h.resp.NotFoundErorr(w, r, err)
+		default:
+			h.resp.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if workout.UserID != u.ID {
+		h.resp.ForbiddenError(w, r, ErrNotWorkoutOwner)
+		return nil, false
+	}
+
+	return workout, true
 }
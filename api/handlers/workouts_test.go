@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testWorkout struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	Exercises []struct {
+		ExerciseID int64 `json:"exercise_id"`
+		Position   int   `json:"position"`
+	} `json:"workout_exercises"`
+}
+
+// createWorkout creates an exercise and a workout with it, returning the
+// ids of both.
+func (ts *testServer) createWorkout(t *testing.T, token, name string) (int64, int64) {
+	t.Helper()
+
+	var exercise struct {
+		ID int64 `json:"id"`
+	}
+	body := map[string]any{"name": "squat", "description": "legs", "muscles": []string{"legs"}}
+	ts.expect(t, http.StatusCreated, http.MethodPost, "/exercises", token, body, &exercise)
+
+	workout := map[string]any{
+		"name":      name,
+		"exercises": []map[string]any{{"exercise_id": exercise.ID, "duration": 60}},
+	}
+	ts.expect(t, http.StatusOK, http.MethodPost, "/workouts", token, workout, nil)
+
+	var workouts []testWorkout
+	path := fmt.Sprintf("/workouts/user/%d", ts.userID(t, token))
+	ts.expect(t, http.StatusOK, http.MethodGet, path, "", nil, &workouts)
+	for _, w := range workouts {
+		if w.Name == name {
+			return w.ID, exercise.ID
+		}
+	}
+
+	t.Fatalf("workout %q was not created", name)
+	return 0, 0
+}
+
+func TestWorkoutsCRUD(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		id, exerciseID := ts.createWorkout(t, token, "legs day")
+		path := fmt.Sprintf("/workouts/%d", id)
+
+		var w testWorkout
+		ts.expect(t, http.StatusOK, http.MethodGet, path, "", nil, &w)
+		if w.Name != "legs day" || len(w.Exercises) != 1 || w.Exercises[0].ExerciseID != exerciseID {
+			t.Fatalf("got workout %+v", w)
+		}
+
+		patch := map[string]any{
+			"name": "leg day",
+			"exercises": []map[string]any{
+				{"exercise_id": exerciseID, "duration": 30},
+				{"exercise_id": exerciseID, "duration": 45},
+			},
+		}
+		ts.expect(t, http.StatusOK, http.MethodPatch, path, token, patch, &w)
+		if w.Name != "leg day" || len(w.Exercises) != 1 {
+			t.Fatalf("got updated workout %+v", w)
+		}
+
+		ts.expect(t, http.StatusNoContent, http.MethodDelete, path, token, nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodGet, path, "", nil, nil)
+		ts.expect(t, http.StatusNotFound, http.MethodDelete, path, token, nil, nil)
+	})
+}
+
+func TestWorkoutOwner(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		owner := ts.login(t, "alice")
+		other := ts.login(t, "bobby")
+		id, _ := ts.createWorkout(t, owner, "legs day")
+		path := fmt.Sprintf("/workouts/%d", id)
+
+		ts.expect(t, http.StatusForbidden, http.MethodPatch, path, other, map[string]any{"name": "mine"}, nil)
+		ts.expect(t, http.StatusForbidden, http.MethodDelete, path, other, nil, nil)
+		ts.expect(t, http.StatusOK, http.MethodGet, path, "", nil, nil)
+	})
+}
+
+func TestActiveWorkout(t *testing.T) {
+	eachStore(t, func(t *testing.T, ts *testServer) {
+		token := ts.login(t, "alice")
+		id, exerciseID := ts.createWorkout(t, token, "legs day")
+
+		start := map[string]any{"workout_id": id}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active", token, start, nil)
+		ts.expect(t, http.StatusConflict, http.MethodPost, "/workouts/active", token, start, nil)
+
+		set := map[string]any{"exercise_id": exerciseID, "reps": 5, "weight": 100}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active/sets", token, set, nil)
+
+		var finished struct {
+			ID   int64 `json:"id"`
+			Sets []any `json:"sets"`
+		}
+		ts.expect(t, http.StatusCreated, http.MethodPost, "/workouts/active/finish", token, nil, &finished)
+		if finished.ID == 0 || len(finished.Sets) != 1 {
+			t.Fatalf("got finished workout %+v", finished)
+		}
+		ts.expect(t, http.StatusNotFound, http.MethodPost, "/workouts/active/finish", token, nil, nil)
+
+		other := ts.login(t, "bobby")
+		path := fmt.Sprintf("/workouts/history/%d", finished.ID)
+		ts.expect(t, http.StatusOK, http.MethodGet, path, token, nil, nil)
+		ts.expect(t, http.StatusForbidden, http.MethodGet, path, other, nil, nil)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -16,7 +17,10 @@ import (
 
 type userKey string
 
-const UserCtx userKey = "user"
+const (
+	UserCtx    userKey = "user"
+	SessionCtx userKey = "session"
+)
 
 type Middleware struct {
 	store         store.Storage
@@ -69,7 +73,66 @@ func (m *Middleware) AuthTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if !user.IsActive {
+			m.resp.ForbiddenError(w, r, fmt.Errorf("user is not activated"))
+			return
+		}
+
+		// tokens issued before the last password change are no longer valid
+		iat, err := claims.GetIssuedAt()
+		if err != nil || iat == nil {
+			m.resp.UnauthorizedError(w, r, fmt.Errorf("token issued at is missing"))
+			return
+		}
+		if user.PasswordChangedAt.Valid && iat.Unix() < user.PasswordChangedAt.Time.Unix() {
+			m.resp.UnauthorizedError(w, r, fmt.Errorf("token has been revoked"))
+			return
+		}
+
+		sessionID, _ := claims["jti"].(string)
+		if sessionID == "" {
+			m.resp.UnauthorizedError(w, r, fmt.Errorf("token session is missing"))
+			return
+		}
+
+		session, err := m.store.Sessions.GetByID(ctx, sessionID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				m.resp.UnauthorizedError(w, r, fmt.Errorf("token has been revoked"))
+			default:
+				m.resp.InternalServerError(w, r, err)
+			}
+			return
+		}
+		if session.RevokedAt.Valid || session.UserID != user.ID {
+			m.resp.UnauthorizedError(w, r, fmt.Errorf("token has been revoked"))
+			return
+		}
+
 		ctx = context.WithValue(ctx, UserCtx, user)
+		ctx = context.WithValue(ctx, SessionCtx, session)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireRole allows only users with one of the roles, it must be used after
+// AuthTokenMiddleware.
+func (m *Middleware) RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := r.Context().Value(UserCtx).(*store.User)
+			if user == nil {
+				m.resp.UnauthorizedError(w, r, fmt.Errorf("user is not authenticated"))
+				return
+			}
+
+			if !slices.Contains(roles, user.Role) {
+				m.resp.ForbiddenError(w, r, fmt.Errorf("user role %q is not allowed", user.Role))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
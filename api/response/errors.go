@@ -73,3 +73,17 @@ func (resp *Responser) UnauthorizedError(w http.ResponseWriter, r *http.Request,
 
 	WriteJSONError(w, http.StatusUnauthorized, err.Error())
 }
+
+func (resp *Responser) ForbiddenError(w http.ResponseWriter, r *http.Request, err error) {
+	resp.logger.Warnw(
+		"forbidden error",
+		"method",
+		r.Method,
+		"path",
+		r.URL.Path,
+		"error",
+		err.Error(),
+	)
+
+	WriteJSONError(w, http.StatusForbidden, err.Error())
+}
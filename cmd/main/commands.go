@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stanislavCasciuc/atom-fit/db"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/foodimport"
+)
+
+// runCommand runs the subcommand named by the first argument instead of the
+// API server.
+func runCommand(cfg config.Config, logger *zap.SugaredLogger, args []string) error {
+	switch args[0] {
+	case "import-foods":
+		return importFoods(cfg, logger, args[1:])
+	case "migrate":
+		return migrate(cfg, logger, args[1:])
+	case "config":
+		return printConfig(cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func importFoods(cfg config.Config, logger *zap.SugaredLogger, args []string) error {
+	fs := flag.NewFlagSet("import-foods", flag.ContinueOnError)
+	format := fs.String(
+		"format",
+		foodimport.FormatOFFCSV,
+		"dump format: "+foodimport.FormatOFFCSV+", "+foodimport.FormatOFFJSONL+" or "+foodimport.FormatUSDA,
+	)
+	path := fs.String("file", "", "dump file, or the directory of the extracted CSV files for usda")
+	batch := fs.Int("batch", 500, "foods upserted per transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("import-foods: -file is required")
+	}
+
+	conn, err := db.New(
+		cfg.DB.Addr,
+		cfg.DB.MaxOpenConns,
+		cfg.DB.MaxIdleConns,
+		cfg.DB.MaxIdleTime,
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	foods := newStorage(cfg.DB.Addr, conn).Foods
+	res, err := foodimport.Import(context.Background(), foods, *format, *path, *batch)
+	logger.Infow("foods import finished", "imported", res.Imported, "skipped", res.Skipped)
+	return err
+}
+
+const migrateUsage = "usage: migrate up | down [n|all] | status | version | force <version>"
+
+func migrate(cfg config.Config, logger *zap.SugaredLogger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	conn, err := db.New(
+		cfg.DB.Addr,
+		cfg.DB.MaxOpenConns,
+		cfg.DB.MaxIdleConns,
+		cfg.DB.MaxIdleTime,
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	driver := db.Driver(cfg.DB.Addr)
+	migrator, err := db.NewMigrator(conn, driver, db.MigrationsFor(driver))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			logger.Infow("migration applied", "version", m.Version, "name", m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			logger.Infow("no pending migrations", "version", migrator.Latest())
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = 0
+			} else if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			logger.Infow("migration reverted", "version", m.Version, "name", m.Name)
+		}
+		return err
+	case "status":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+		for _, m := range migrator.Migrations() {
+			status := "pending"
+			switch {
+			case m.Version == version && dirty:
+				status = "dirty"
+			case m.Version <= version:
+				status = "applied"
+			}
+			fmt.Fprintf(tw, "%06d\t%s\t%s\n", m.Version, m.Name, status)
+		}
+		return tw.Flush()
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("migrate force: invalid version %q", args[1])
+		}
+		return migrator.Force(ctx, uint(version))
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+const configUsage = "usage: config print [--redacted]"
+
+// printConfig writes the loaded config as YAML, which can be used as the
+// CONFIG_FILE of another run.
+func printConfig(cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	redact := fs.Bool("redacted", false, "replace the secrets")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *redact {
+		cfg = cfg.Redacted()
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	return enc.Close()
+}
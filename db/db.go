@@ -3,11 +3,43 @@ package db
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
 )
 
+// sqlitePragmas are set on every SQLite connection, foreign keys are off by
+// default in SQLite and the store relies on their cascades.
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// Driver returns the driver of the address, sqlite for sqlite:// addresses
+// and postgres for everything else.
+func Driver(addr string) string {
+	if strings.HasPrefix(addr, "sqlite:") {
+		return DriverSQLite
+	}
+	return DriverPostgres
+}
+
+// New opens the database of the address. A sqlite://path address opens, and
+// creates when missing, the SQLite file at path, sqlite://:memory: opens an
+// in-memory database.
 func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", addr)
+	driver, dsn := Driver(addr), addr
+	if driver == DriverSQLite {
+		dsn = sqliteDSN(addr)
+		// SQLite allows a single writer, sharing one connection avoids busy
+		// errors between transactions and keeps an in-memory database alive
+		maxOpenConns, maxIdleConns = 1, 1
+	}
+
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -19,6 +51,9 @@ func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.
 	if err != nil {
 		return nil, err
 	}
+	if driver == DriverSQLite {
+		duration = 0
+	}
 	db.SetConnMaxIdleTime(duration)
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
@@ -30,3 +65,11 @@ func New(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.
 
 	return db, nil
 }
+
+func sqliteDSN(addr string) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(addr, "sqlite:"), "//")
+	if strings.Contains(path, "?") {
+		return "file:" + path + "&" + sqlitePragmas
+	}
+	return "file:" + path + "?" + sqlitePragmas
+}
@@ -0,0 +1,344 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed migrations/*.sql sqlite_migrations/*.sql
+var migrationsFS embed.FS
+
+var (
+	// Migrations are the Postgres migrations compiled into the binary.
+	Migrations, _ = fs.Sub(migrationsFS, "migrations")
+	// SQLiteMigrations are the SQLite migrations compiled into the binary,
+	// they are a separate set which keeps its own versions.
+	SQLiteMigrations, _ = fs.Sub(migrationsFS, "sqlite_migrations")
+)
+
+// MigrationsFor returns the migrations of the driver.
+func MigrationsFor(driver string) fs.FS {
+	if driver == DriverSQLite {
+		return SQLiteMigrations
+	}
+	return Migrations
+}
+
+var (
+	ErrDirtySchema  = errors.New("database schema is dirty")
+	ErrSchemaBehind = errors.New("database schema is behind the code")
+	ErrNoMigrations = errors.New("no migrations found")
+)
+
+// migrationLockID is the advisory lock held while migrating, so that several
+// instances started at once do not apply the same migration twice.
+const migrationLockID = 7246812390
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a numbered pair of up and down scripts.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies migrations and keeps the current version in the
+// schema_migrations table, the same table the migrate CLI uses, so databases
+// migrated with either of them stay compatible.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, ErrNoMigrations
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrations returns all known migrations ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Latest is the version the code expects the database to be at.
+func (m *Migrator) Latest() uint {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the current version of the database, 0 when no migration
+// was applied yet.
+func (m *Migrator) Version(ctx context.Context) (uint, bool, error) {
+	if err := ensureVersionTable(ctx, m.db); err != nil {
+		return 0, false, err
+	}
+	return readVersion(ctx, m.db)
+}
+
+// Check returns an error when the database is dirty or has not been migrated
+// to the latest version. A database ahead of the code is accepted so that an
+// older release can still run next to a newer one.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirtySchema, version)
+	}
+	if version < m.Latest() {
+		return fmt.Errorf(
+			"%w: database is at version %d, the code expects %d",
+			ErrSchemaBehind,
+			version,
+			m.Latest(),
+		)
+	}
+
+	return nil
+}
+
+// Up applies all pending migrations and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version uint) error {
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of migrations, all of them when steps is
+// not positive, and returns the rolled back ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, version uint) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version > version {
+				continue
+			}
+			if steps > 0 && len(reverted) == steps {
+				break
+			}
+
+			if mig.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+			}
+
+			var prev uint
+			if i > 0 {
+				prev = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, mig.Down, prev); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force sets the version without running any migration and clears the dirty
+// flag, it is the way out after a failed migration was fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if err := ensureVersionTable(ctx, m.db); err != nil {
+		return err
+	}
+
+	return withTx(ctx, m.db, func(tx *sql.Tx) error {
+		return setVersion(ctx, tx, version)
+	})
+}
+
+// locked runs fn on a single connection holding the migration lock, with the
+// current version read after the lock was taken. SQLite has no advisory
+// locks, its database is opened with a single connection instead.
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn, uint) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.driver == DriverPostgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+	}
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirtySchema, version)
+	}
+
+	return fn(conn, version)
+}
+
+// apply runs a script and records the new version in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, version uint) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func ensureVersionTable(ctx context.Context, db execQuerier) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint NOT NULL PRIMARY KEY,
+			dirty boolean NOT NULL
+		)
+	`)
+	return err
+}
+
+func readVersion(ctx context.Context, db execQuerier) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return uint(version), dirty, nil
+}
+
+// setVersion keeps a single row in schema_migrations, no row means version 0.
+func setVersion(ctx context.Context, tx *sql.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`,
+		int64(version),
+	)
+	return err
+}
+
+func withTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,2 +1,2 @@
-DROP EXTENSION IF EXISTS citext;
 DROP TABLE IF EXISTS users;
+DROP EXTENSION IF EXISTS citext;
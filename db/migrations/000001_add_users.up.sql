@@ -5,4 +5,4 @@ CREATE TABLE IF NOT EXISTS users(
 	username varchar(255) UNIQUE NOT NULL,
 	password BYTEA NOT NULL,
 	created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
-)
+);
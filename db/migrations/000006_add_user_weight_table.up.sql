@@ -2,6 +2,6 @@ CREATE TABLE IF NOT EXISTS user_weight(
 	user_id bigint,
   date DATE DEFAULT CURRENT_DATE,
   weight REAL NOT NULL,
-  PRIMARY KEY (user_id, weigh_date),
+  PRIMARY KEY (user_id, date),
   CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id)
 );
@@ -1,14 +1,13 @@
+DROP INDEX IF EXISTS idx_exercise_description;
 
-DROP EXTENSION IF EXISTS pg_trgm;
-
-DROP INDEX IF  EXISTS idx_exercise_description; 
+DROP INDEX IF EXISTS idx_workout_description;
 
-DROP INDEX IF EXISTS idx_workout_description; 
+DROP INDEX IF EXISTS idx_workout_name;
 
-DROP INDEX IF EXISTS idx_workout_name; 
+DROP INDEX IF EXISTS idx_exercise_name;
 
-DROP INDEX IF EXISTS idx_exercise_name; 
+DROP INDEX IF EXISTS idx_exercise_muscles;
 
-DROP INDEX IF EXISTS idx_exercise_muscles; 
+DROP INDEX IF EXISTS idx_user_username;
 
-DROP INDEX IF EXISTS idx_user_username; 
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -1 +1 @@
-ALTER TABLE DROP COLUMN age;
+ALTER TABLE user_attributes DROP COLUMN IF EXISTS age;
@@ -1 +1 @@
-DELETE TABLE IF EXISTS workout_likes; 
+DROP TABLE IF EXISTS workout_likes;
@@ -10,4 +10,4 @@ CREATE TABLE IF NOT EXISTS workout_reviews(
 	CONSTRAINT fk_user FOREIGN KEY(user_id) REFERENCES users(id),
   CONSTRAINT fk_workout FOREIGN KEY(workout_id) REFERENCES workouts(id),
 	CONSTRAINT chk_rating CHECK (rating >= 1 AND rating <= 5)
-)
+);
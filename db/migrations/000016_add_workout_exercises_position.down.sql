@@ -0,0 +1 @@
+ALTER TABLE workout_exercises DROP COLUMN IF EXISTS position;
@@ -0,0 +1 @@
+ALTER TABLE workout_exercises ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS password_resets (
+  token bytea PRIMARY KEY,
+  user_id bigint NOT NULL,
+  exp TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP WITH TIME ZONE;
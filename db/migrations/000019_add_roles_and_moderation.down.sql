@@ -0,0 +1,6 @@
+ALTER TABLE workout_reviews DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE workouts DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE exercises DROP COLUMN IF EXISTS is_hidden;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS role_check;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS finished_workout_sets;
+
+DROP INDEX IF EXISTS idx_finished_workouts_user_ended_at;
+ALTER TABLE finished_workouts DROP COLUMN IF EXISTS started_at;
+ALTER TABLE finished_workouts DROP CONSTRAINT IF EXISTS finished_workouts_pkey;
+ALTER TABLE finished_workouts DROP COLUMN IF EXISTS id;
+ALTER TABLE finished_workouts RENAME COLUMN ended_at TO date;
+ALTER TABLE finished_workouts ADD PRIMARY KEY (date);
@@ -0,0 +1,26 @@
+ALTER TABLE finished_workouts DROP CONSTRAINT IF EXISTS finished_workouts_pkey;
+ALTER TABLE finished_workouts RENAME COLUMN date TO ended_at;
+ALTER TABLE finished_workouts ALTER COLUMN ended_at SET NOT NULL;
+ALTER TABLE finished_workouts ADD COLUMN IF NOT EXISTS id BIGSERIAL PRIMARY KEY;
+ALTER TABLE finished_workouts ADD COLUMN IF NOT EXISTS started_at TIMESTAMP(0) WITH TIME ZONE;
+UPDATE finished_workouts SET started_at = ended_at - make_interval(secs => duration) WHERE started_at IS NULL;
+ALTER TABLE finished_workouts ALTER COLUMN started_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_finished_workouts_user_ended_at ON finished_workouts (user_id, ended_at DESC);
+
+CREATE TABLE IF NOT EXISTS finished_workout_sets (
+  id BIGSERIAL PRIMARY KEY,
+  finished_workout_id bigint NOT NULL,
+  exercise_id bigint NOT NULL,
+  set_number int NOT NULL,
+  reps int NOT NULL DEFAULT 0,
+  weight REAL NOT NULL DEFAULT 0,
+  duration int NOT NULL DEFAULT 0,
+  rpe REAL,
+  UNIQUE (finished_workout_id, exercise_id, set_number),
+  CONSTRAINT fk_finished_workout FOREIGN KEY (finished_workout_id) REFERENCES finished_workouts(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id),
+  CONSTRAINT chk_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+
+CREATE INDEX IF NOT EXISTS idx_finished_workout_sets_exercise_id ON finished_workout_sets (exercise_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS active_workout_sets;
+
+DROP INDEX IF EXISTS idx_active_workouts_updated_at;
+DROP TABLE IF EXISTS active_workouts;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS active_workouts (
+  id BIGSERIAL PRIMARY KEY,
+  user_id bigint UNIQUE NOT NULL,
+  workout_id bigint NOT NULL,
+  started_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  paused_at TIMESTAMP(0) WITH TIME ZONE,
+  paused_duration int NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id),
+  CONSTRAINT fk_workout FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_active_workouts_updated_at ON active_workouts (updated_at);
+
+CREATE TABLE IF NOT EXISTS active_workout_sets (
+  id BIGSERIAL PRIMARY KEY,
+  active_workout_id bigint NOT NULL,
+  exercise_id bigint NOT NULL,
+  set_number int NOT NULL,
+  reps int NOT NULL DEFAULT 0,
+  weight REAL NOT NULL DEFAULT 0,
+  duration int NOT NULL DEFAULT 0,
+  rpe REAL,
+  UNIQUE (active_workout_id, exercise_id, set_number),
+  CONSTRAINT fk_active_workout FOREIGN KEY (active_workout_id) REFERENCES active_workouts(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id),
+  CONSTRAINT chk_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
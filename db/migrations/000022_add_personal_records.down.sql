@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_finished_workout_sets_exercise_workout;
+DROP TABLE IF EXISTS personal_records;
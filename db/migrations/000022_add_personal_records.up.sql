@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS personal_records (
+  user_id bigint NOT NULL,
+  exercise_id bigint NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  value REAL NOT NULL,
+  finished_workout_id bigint NOT NULL,
+  achieved_at TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+  PRIMARY KEY (user_id, exercise_id, kind),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_exercise FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+  CONSTRAINT fk_finished_workout FOREIGN KEY (finished_workout_id) REFERENCES finished_workouts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_finished_workout_sets_exercise_workout ON finished_workout_sets (exercise_id, finished_workout_id);
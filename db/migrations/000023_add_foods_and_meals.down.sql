@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_meals_user_date;
+DROP TABLE IF EXISTS meals;
+DROP TABLE IF EXISTS foods;
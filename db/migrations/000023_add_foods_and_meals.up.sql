@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS foods (
+  id BIGSERIAL PRIMARY KEY,
+  user_id bigint,
+  name VARCHAR(255) NOT NULL,
+  calories REAL NOT NULL DEFAULT 0,
+  proteins REAL NOT NULL DEFAULT 0,
+  fats REAL NOT NULL DEFAULT 0,
+  carbohydrates REAL NOT NULL DEFAULT 0,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS meals (
+  id BIGSERIAL PRIMARY KEY,
+  user_id bigint NOT NULL,
+  food_id bigint NOT NULL,
+  meal VARCHAR(16) NOT NULL,
+  grams REAL NOT NULL,
+  date DATE NOT NULL DEFAULT CURRENT_DATE,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_food FOREIGN KEY (food_id) REFERENCES foods(id),
+  CONSTRAINT chk_meal CHECK (meal IN ('breakfast', 'lunch', 'dinner', 'snack')),
+  CONSTRAINT chk_grams CHECK (grams > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_meals_user_date ON meals (user_id, date);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_food_name;
+
+ALTER TABLE foods DROP COLUMN IF EXISTS barcode;
@@ -0,0 +1,5 @@
+ALTER TABLE foods ADD COLUMN IF NOT EXISTS barcode VARCHAR(64) UNIQUE;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_food_name ON foods USING gin(name gin_trgm_ops);
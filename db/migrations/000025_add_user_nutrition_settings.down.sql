@@ -0,0 +1,12 @@
+ALTER TABLE user_attributes
+  DROP CONSTRAINT IF EXISTS macro_ratios_check,
+  DROP CONSTRAINT IF EXISTS formula_check,
+  DROP CONSTRAINT IF EXISTS activity_level_check;
+
+ALTER TABLE user_attributes
+  DROP COLUMN IF EXISTS carb_ratio,
+  DROP COLUMN IF EXISTS fat_ratio,
+  DROP COLUMN IF EXISTS protein_ratio,
+  DROP COLUMN IF EXISTS body_fat,
+  DROP COLUMN IF EXISTS formula,
+  DROP COLUMN IF EXISTS activity_level;
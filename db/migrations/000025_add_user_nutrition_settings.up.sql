@@ -0,0 +1,15 @@
+ALTER TABLE user_attributes
+  ADD COLUMN IF NOT EXISTS activity_level VARCHAR(16) NOT NULL DEFAULT 'moderate',
+  ADD COLUMN IF NOT EXISTS formula VARCHAR(16) NOT NULL DEFAULT 'mifflin',
+  ADD COLUMN IF NOT EXISTS body_fat REAL,
+  ADD COLUMN IF NOT EXISTS protein_ratio REAL,
+  ADD COLUMN IF NOT EXISTS fat_ratio REAL,
+  ADD COLUMN IF NOT EXISTS carb_ratio REAL;
+
+ALTER TABLE user_attributes
+  ADD CONSTRAINT activity_level_check CHECK (activity_level IN ('sedentary', 'light', 'moderate', 'active', 'very_active')),
+  ADD CONSTRAINT formula_check CHECK (formula IN ('mifflin', 'harris_benedict', 'katch_mcardle')),
+  ADD CONSTRAINT macro_ratios_check CHECK (
+    (protein_ratio IS NULL AND fat_ratio IS NULL AND carb_ratio IS NULL) OR
+    (protein_ratio IS NOT NULL AND fat_ratio IS NOT NULL AND carb_ratio IS NOT NULL)
+  );
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS email_changes;
+
+ALTER TABLE user_attributes ADD COLUMN IF NOT EXISTS age INT NOT NULL DEFAULT 0;
+UPDATE user_attributes SET age = date_part('year', age(birth_date)) WHERE birth_date IS NOT NULL;
+ALTER TABLE user_attributes DROP COLUMN IF EXISTS birth_date;
@@ -0,0 +1,11 @@
+ALTER TABLE user_attributes ADD COLUMN IF NOT EXISTS birth_date DATE;
+UPDATE user_attributes SET birth_date = CURRENT_DATE - make_interval(years => age) WHERE age > 0;
+ALTER TABLE user_attributes DROP COLUMN IF EXISTS age;
+
+CREATE TABLE IF NOT EXISTS email_changes (
+  token bytea PRIMARY KEY,
+  user_id bigint NOT NULL,
+  email citext NOT NULL,
+  exp TIMESTAMP(0) WITH TIME ZONE NOT NULL,
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS measurements (
+  user_id bigint NOT NULL,
+  metric VARCHAR(32) NOT NULL,
+  date DATE NOT NULL DEFAULT CURRENT_DATE,
+  value REAL NOT NULL,
+  unit VARCHAR(8) NOT NULL,
+  PRIMARY KEY (user_id, metric, date),
+  CONSTRAINT fk_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT metric_check CHECK (metric IN ('body_fat', 'waist', 'chest', 'hips', 'arms', 'thighs', 'resting_heart_rate')),
+  CONSTRAINT value_check CHECK (value > 0)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS units_check;
+ALTER TABLE users DROP COLUMN IF EXISTS units;
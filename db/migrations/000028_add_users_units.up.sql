@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS units VARCHAR(8) NOT NULL DEFAULT 'metric';
+ALTER TABLE users ADD CONSTRAINT units_check CHECK (units IN ('metric', 'imperial'));
@@ -0,0 +1,4 @@
+ALTER TABLE personal_records DROP CONSTRAINT IF EXISTS fk_finished_workout;
+DELETE FROM personal_records WHERE finished_workout_id IS NULL;
+ALTER TABLE personal_records ALTER COLUMN finished_workout_id SET NOT NULL;
+ALTER TABLE personal_records ADD CONSTRAINT fk_finished_workout FOREIGN KEY (finished_workout_id) REFERENCES finished_workouts(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE personal_records DROP CONSTRAINT IF EXISTS fk_finished_workout;
+ALTER TABLE personal_records ALTER COLUMN finished_workout_id DROP NOT NULL;
+ALTER TABLE personal_records ADD CONSTRAINT fk_finished_workout FOREIGN KEY (finished_workout_id) REFERENCES finished_workouts(id) ON DELETE SET NULL;
@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS measurements;
+DROP TABLE IF EXISTS meals;
+DROP TABLE IF EXISTS foods;
+DROP TABLE IF EXISTS personal_records;
+DROP TABLE IF EXISTS active_workout_sets;
+DROP TABLE IF EXISTS active_workouts;
+DROP TABLE IF EXISTS finished_workout_sets;
+DROP TABLE IF EXISTS finished_workouts;
+DROP TABLE IF EXISTS workout_reviews;
+DROP TABLE IF EXISTS workout_likes;
+DROP TABLE IF EXISTS workout_exercises;
+DROP TABLE IF EXISTS workouts;
+DROP TABLE IF EXISTS exercise_likes;
+DROP TABLE IF EXISTS exercises;
+DROP TABLE IF EXISTS user_weight;
+DROP TABLE IF EXISTS user_attributes;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS email_changes;
+DROP TABLE IF EXISTS password_resets;
+DROP TABLE IF EXISTS invitation;
+DROP TABLE IF EXISTS users;
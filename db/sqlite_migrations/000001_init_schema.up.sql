@@ -0,0 +1,230 @@
+-- The SQLite schema matches the Postgres one at version 28. Emails compare
+-- case insensitively like citext, muscles are a JSON array, timestamps are
+-- stored in UTC as 'YYYY-MM-DD HH:MM:SS' and dates as 'YYYY-MM-DD'.
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email TEXT COLLATE NOCASE UNIQUE NOT NULL,
+  username VARCHAR(255) UNIQUE NOT NULL,
+  password BLOB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  is_active BOOLEAN NOT NULL DEFAULT FALSE,
+  password_changed_at TIMESTAMP,
+  role VARCHAR(20) NOT NULL DEFAULT 'user',
+  units VARCHAR(8) NOT NULL DEFAULT 'metric',
+  CONSTRAINT role_check CHECK (role IN ('user', 'coach', 'admin')),
+  CONSTRAINT units_check CHECK (units IN ('metric', 'imperial'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_username ON users (username);
+
+CREATE TABLE IF NOT EXISTS invitation (
+  token TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL,
+  exp TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS password_resets (
+  token TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  exp TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS email_changes (
+  token TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  email TEXT COLLATE NOCASE NOT NULL,
+  exp TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+  id TEXT PRIMARY KEY,
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  refresh_token TEXT UNIQUE NOT NULL,
+  exp TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS user_attributes (
+  user_id INTEGER PRIMARY KEY REFERENCES users (id),
+  is_male BOOLEAN NOT NULL DEFAULT TRUE,
+  height INTEGER NOT NULL DEFAULT 175,
+  goal VARCHAR(100) NOT NULL DEFAULT 'lose',
+  weight_goal REAL NOT NULL DEFAULT 65,
+  birth_date DATE,
+  activity_level VARCHAR(16) NOT NULL DEFAULT 'moderate',
+  formula VARCHAR(16) NOT NULL DEFAULT 'mifflin',
+  body_fat REAL,
+  protein_ratio REAL,
+  fat_ratio REAL,
+  carb_ratio REAL,
+  CONSTRAINT goal_check CHECK (goal IN ('lose', 'gain', 'maintain')),
+  CONSTRAINT activity_level_check CHECK (activity_level IN ('sedentary', 'light', 'moderate', 'active', 'very_active')),
+  CONSTRAINT formula_check CHECK (formula IN ('mifflin', 'harris_benedict', 'katch_mcardle')),
+  CONSTRAINT macro_ratios_check CHECK (
+    (protein_ratio IS NULL AND fat_ratio IS NULL AND carb_ratio IS NULL) OR
+    (protein_ratio IS NOT NULL AND fat_ratio IS NOT NULL AND carb_ratio IS NOT NULL)
+  )
+);
+
+CREATE TABLE IF NOT EXISTS user_weight (
+  user_id INTEGER REFERENCES users (id),
+  date DATE NOT NULL DEFAULT CURRENT_DATE,
+  weight REAL NOT NULL,
+  PRIMARY KEY (user_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS exercises (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  name VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  is_duration BOOLEAN NOT NULL DEFAULT FALSE,
+  duration INTEGER NOT NULL DEFAULT 0,
+  tutorial_link TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  muscles TEXT NOT NULL DEFAULT '[]',
+  is_hidden BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS exercise_likes (
+  user_id INTEGER REFERENCES users (id),
+  exercise_id INTEGER REFERENCES exercises (id),
+  PRIMARY KEY (user_id, exercise_id)
+);
+
+CREATE TABLE IF NOT EXISTS workouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  name VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  tutorial_link TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  is_hidden BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS workout_exercises (
+  workout_id INTEGER REFERENCES workouts (id),
+  exercise_id INTEGER REFERENCES exercises (id),
+  duration INTEGER NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (workout_id, exercise_id)
+);
+
+CREATE TABLE IF NOT EXISTS workout_likes (
+  workout_id INTEGER REFERENCES workouts (id),
+  user_id INTEGER REFERENCES users (id),
+  PRIMARY KEY (user_id, workout_id)
+);
+
+CREATE TABLE IF NOT EXISTS workout_reviews (
+  user_id INTEGER REFERENCES users (id),
+  workout_id INTEGER REFERENCES workouts (id),
+  rating INTEGER NOT NULL DEFAULT 5,
+  title VARCHAR(225) NOT NULL,
+  content TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (user_id, workout_id),
+  CONSTRAINT chk_rating CHECK (rating >= 1 AND rating <= 5)
+);
+
+CREATE TABLE IF NOT EXISTS finished_workouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id),
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  started_at TIMESTAMP NOT NULL,
+  ended_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  duration INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_finished_workouts_user_ended_at ON finished_workouts (user_id, ended_at DESC);
+
+CREATE TABLE IF NOT EXISTS finished_workout_sets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  finished_workout_id INTEGER NOT NULL REFERENCES finished_workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id),
+  set_number INTEGER NOT NULL,
+  reps INTEGER NOT NULL DEFAULT 0,
+  weight REAL NOT NULL DEFAULT 0,
+  duration INTEGER NOT NULL DEFAULT 0,
+  rpe REAL,
+  UNIQUE (finished_workout_id, exercise_id, set_number),
+  CONSTRAINT chk_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+
+CREATE INDEX IF NOT EXISTS idx_finished_workout_sets_exercise_workout ON finished_workout_sets (exercise_id, finished_workout_id);
+
+CREATE TABLE IF NOT EXISTS active_workouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER UNIQUE NOT NULL REFERENCES users (id),
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  paused_at TIMESTAMP,
+  paused_duration INTEGER NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_active_workouts_updated_at ON active_workouts (updated_at);
+
+CREATE TABLE IF NOT EXISTS active_workout_sets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  active_workout_id INTEGER NOT NULL REFERENCES active_workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id),
+  set_number INTEGER NOT NULL,
+  reps INTEGER NOT NULL DEFAULT 0,
+  weight REAL NOT NULL DEFAULT 0,
+  duration INTEGER NOT NULL DEFAULT 0,
+  rpe REAL,
+  UNIQUE (active_workout_id, exercise_id, set_number),
+  CONSTRAINT chk_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+
+CREATE TABLE IF NOT EXISTS personal_records (
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id) ON DELETE CASCADE,
+  kind VARCHAR(32) NOT NULL,
+  value REAL NOT NULL,
+  finished_workout_id INTEGER NOT NULL REFERENCES finished_workouts (id) ON DELETE CASCADE,
+  achieved_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, exercise_id, kind)
+);
+
+CREATE TABLE IF NOT EXISTS foods (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+  barcode VARCHAR(64) UNIQUE,
+  name VARCHAR(255) NOT NULL,
+  calories REAL NOT NULL DEFAULT 0,
+  proteins REAL NOT NULL DEFAULT 0,
+  fats REAL NOT NULL DEFAULT 0,
+  carbohydrates REAL NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS meals (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  food_id INTEGER NOT NULL REFERENCES foods (id),
+  meal VARCHAR(16) NOT NULL,
+  grams REAL NOT NULL,
+  date DATE NOT NULL DEFAULT CURRENT_DATE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT chk_meal CHECK (meal IN ('breakfast', 'lunch', 'dinner', 'snack')),
+  CONSTRAINT chk_grams CHECK (grams > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_meals_user_date ON meals (user_id, date);
+
+CREATE TABLE IF NOT EXISTS measurements (
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  metric VARCHAR(32) NOT NULL,
+  date DATE NOT NULL DEFAULT CURRENT_DATE,
+  value REAL NOT NULL,
+  unit VARCHAR(8) NOT NULL,
+  PRIMARY KEY (user_id, metric, date),
+  CONSTRAINT metric_check CHECK (metric IN ('body_fat', 'waist', 'chest', 'hips', 'arms', 'thighs', 'resting_heart_rate')),
+  CONSTRAINT value_check CHECK (value > 0)
+);
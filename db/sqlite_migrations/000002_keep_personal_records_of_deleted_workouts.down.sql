@@ -0,0 +1,13 @@
+CREATE TABLE personal_records_old (
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id) ON DELETE CASCADE,
+  kind VARCHAR(32) NOT NULL,
+  value REAL NOT NULL,
+  finished_workout_id INTEGER NOT NULL REFERENCES finished_workouts (id) ON DELETE CASCADE,
+  achieved_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, exercise_id, kind)
+);
+
+INSERT INTO personal_records_old SELECT * FROM personal_records WHERE finished_workout_id IS NOT NULL;
+DROP TABLE personal_records;
+ALTER TABLE personal_records_old RENAME TO personal_records;
@@ -0,0 +1,13 @@
+CREATE TABLE personal_records_new (
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id) ON DELETE CASCADE,
+  kind VARCHAR(32) NOT NULL,
+  value REAL NOT NULL,
+  finished_workout_id INTEGER REFERENCES finished_workouts (id) ON DELETE SET NULL,
+  achieved_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (user_id, exercise_id, kind)
+);
+
+INSERT INTO personal_records_new SELECT * FROM personal_records;
+DROP TABLE personal_records;
+ALTER TABLE personal_records_new RENAME TO personal_records;
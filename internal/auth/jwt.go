@@ -2,23 +2,82 @@ package auth
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
 )
 
 type JWTAuthenticator struct {
 	secret string
 	aud    string
+	// signing is nil when tokens are signed with the shared secret
+	signing *Key
+	keys    map[string]Key
 }
 
 func New(secret string, aud string) *JWTAuthenticator {
-	return &JWTAuthenticator{secret, aud}
+	return &JWTAuthenticator{secret: secret, aud: aud}
+}
+
+// NewFromConfig uses the configured key files when there are any and falls
+// back to the shared secret otherwise.
+func NewFromConfig(cfg config.Auth) (*JWTAuthenticator, error) {
+	if len(cfg.Keys) == 0 {
+		return New(cfg.Secret, cfg.Aud), nil
+	}
+
+	keys := make([]Key, 0, len(cfg.Keys))
+	for _, kf := range cfg.Keys {
+		k, err := LoadKeyFile(kf.ID, kf.Path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	signingID := cfg.SigningKeyID
+	if signingID == "" {
+		signingID = cfg.Keys[0].ID
+	}
+
+	return NewWithKeys(cfg.Aud, keys, signingID)
+}
+
+// NewWithKeys returns an authenticator which signs tokens with the key
+// signingID and accepts tokens signed by any of the keys, selected by kid.
+func NewWithKeys(aud string, keys []Key, signingID string) (*JWTAuthenticator, error) {
+	a := &JWTAuthenticator{aud: aud, keys: make(map[string]Key, len(keys))}
+	for _, k := range keys {
+		if _, ok := a.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		a.keys[k.ID] = k
+	}
+
+	k, ok := a.keys[signingID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not configured", signingID)
+	}
+	if k.Private == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingID)
+	}
+	a.signing = &k
+
+	return a, nil
 }
 
 func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if a.signing == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(a.secret))
+	}
+
+	token := jwt.NewWithClaims(a.signing.Method, claims)
+	token.Header["kid"] = a.signing.ID
 
-	tokenString, err := token.SignedString([]byte(a.secret))
+	tokenString, err := token.SignedString(a.signing.Private)
 	if err != nil {
 		return "", err
 	}
@@ -27,16 +86,60 @@ func (a *JWTAuthenticator) GenerateToken(claims jwt.Claims) (string, error) {
 }
 
 func (a *JWTAuthenticator) ValidateToken(token string) (*jwt.Token, error) {
-	return jwt.Parse(token, func(t *jwt.Token) (any, error) {
+	return jwt.Parse(token, a.keyFunc,
+		jwt.WithExpirationRequired(),
+		jwt.WithAudience(a.aud),
+		jwt.WithIssuer(a.aud),
+		jwt.WithValidMethods(a.validMethods()),
+	)
+}
+
+// JWKS returns the public keys which verify tokens, it is empty for the
+// shared secret.
+func (a *JWTAuthenticator) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(a.keys))}
+	for _, k := range a.keys {
+		set.Keys = append(set.Keys, k.JWK())
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
+
+func (a *JWTAuthenticator) keyFunc(t *jwt.Token) (any, error) {
+	if a.signing == nil {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected singing method: %v", t.Header["alg"])
 		}
 
 		return []byte(a.secret), nil
-	},
-		jwt.WithExpirationRequired(),
-		jwt.WithAudience(a.aud),
-		jwt.WithIssuer(a.aud),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}),
-	)
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	k, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, fmt.Errorf("unexpected singing method: %v", t.Header["alg"])
+	}
+
+	return k.Public, nil
+}
+
+func (a *JWTAuthenticator) validMethods() []string {
+	if a.signing == nil {
+		return []string{jwt.SigningMethodHS256.Name}
+	}
+
+	methods := make([]string, 0, len(a.keys))
+	seen := make(map[string]bool)
+	for _, k := range a.keys {
+		if !seen[k.Method.Alg()] {
+			seen[k.Method.Alg()] = true
+			methods = append(methods, k.Method.Alg())
+		}
+	}
+
+	return methods
 }
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is an asymmetric key identified by its kid. Keys without a private part
+// can only verify tokens, which is used for rotated out keys.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private any
+	Public  any
+}
+
+// LoadKeyFile reads a PEM encoded RSA or Ed25519 key. Private keys may be in
+// PKCS#1 or PKCS#8 form, public keys in PKIX form.
+func LoadKeyFile(id, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %s: no PEM data found in %s", id, path)
+	}
+
+	k := Key{ID: id}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k.Private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k.Private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k.Public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	switch priv := k.Private.(type) {
+	case *rsa.PrivateKey:
+		k.Public = &priv.PublicKey
+	case ed25519.PrivateKey:
+		k.Public = priv.Public()
+	case nil:
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported private key type %T", id, priv)
+	}
+
+	switch k.Public.(type) {
+	case *rsa.PublicKey:
+		k.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		k.Method = jwt.SigningMethodEdDSA
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported public key type %T", id, k.Public)
+	}
+
+	return k, nil
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (k Key) JWK() JWK {
+	jwk := JWK{
+		Kid: k.ID,
+		Use: "sig",
+		Alg: k.Method.Alg(),
+	}
+
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+
+	return jwk
+}
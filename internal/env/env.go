@@ -1,27 +0,0 @@
-package env
-
-import (
-	"os"
-	"strconv"
-)
-
-func EnvString(key string, fallback string) string {
-	val := os.Getenv(key)
-	if val == "" {
-		return fallback
-	}
-	return val
-}
-
-func IntEnv(key string, fallback int) int {
-	val := os.Getenv(key)
-	if val == "" {
-		return fallback
-	}
-	intVal, err := strconv.Atoi(val)
-	if err != nil {
-		return fallback
-	}
-
-	return intVal
-}
@@ -1,32 +1,82 @@
 package config
 
-import "time"
+import (
+	"net/url"
+	"time"
+)
+
+// DefaultSecret is the development JWT secret, it is refused in prod.
+const DefaultSecret = "secret"
+
+const redacted = "REDACTED"
 
 type Config struct {
-	Addr         string
-	CompleteAddr string
-	DB           DbConfig
-	Env          string
-	Mail         MailCfg
-	Auth         Auth
+	Addr         string   `yaml:"addr"          toml:"addr"`
+	CompleteAddr string   `yaml:"complete_addr" toml:"complete_addr"`
+	DB           DbConfig `yaml:"db"            toml:"db"`
+	Env          string   `yaml:"env"           toml:"env"`
+	Mail         MailCfg  `yaml:"mail"          toml:"mail"`
+	Auth         Auth     `yaml:"auth"          toml:"auth"`
+	CORS         CORS     `yaml:"cors"          toml:"cors"`
+	// ActiveWorkoutTTL is how long an untouched active workout is kept
+	ActiveWorkoutTTL time.Duration `yaml:"active_workout_ttl" toml:"active_workout_ttl"`
+	SweepInterval    time.Duration `yaml:"sweep_interval"     toml:"sweep_interval"`
+	// ShutdownTimeout is how long requests and background workers are
+	// awaited on shutdown
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
 }
 
 type MailCfg struct {
-	Addr     string
-	Host     string
-	Port     int
-	Password string
+	Backend  string `yaml:"backend"   toml:"backend"`
+	Addr     string `yaml:"addr"      toml:"addr"`
+	Host     string `yaml:"host"      toml:"host"`
+	Port     int    `yaml:"port"      toml:"port"`
+	Password string `yaml:"password"  toml:"password"`
+	SpoolDir string `yaml:"spool_dir" toml:"spool_dir"`
 }
 
 type DbConfig struct {
-	Addr         string
-	MaxOpenConns int
-	MaxIdleConns int
-	MaxIdleTime  string
+	Addr         string `yaml:"addr"           toml:"addr"`
+	MaxOpenConns int    `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns int    `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	MaxIdleTime  string `yaml:"max_idle_time"  toml:"max_idle_time"`
+	// AutoMigrate applies pending migrations on startup
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
 }
 
 type Auth struct {
-	Secret string
-	Aud    string
-	Iat    time.Duration
+	Secret       string        `yaml:"secret"         toml:"secret"`
+	Aud          string        `yaml:"aud"            toml:"aud"`
+	Iat          time.Duration `yaml:"iat"            toml:"iat"`
+	RefreshExp   time.Duration `yaml:"refresh_exp"    toml:"refresh_exp"`
+	Keys         []KeyFile     `yaml:"keys"           toml:"keys"`
+	SigningKeyID string        `yaml:"signing_key_id" toml:"signing_key_id"`
+}
+
+// KeyFile is a PEM encoded signing key, ID is used as the kid of the tokens.
+type KeyFile struct {
+	ID   string `yaml:"id"   toml:"id"`
+	Path string `yaml:"path" toml:"path"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// Redacted returns a copy of the config with the secrets replaced, so it
+// can be printed or logged.
+func (c Config) Redacted() Config {
+	if c.Auth.Secret != "" {
+		c.Auth.Secret = redacted
+	}
+	if c.Mail.Password != "" {
+		c.Mail.Password = redacted
+	}
+	if u, err := url.Parse(c.DB.Addr); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			c.DB.Addr = u.String()
+		}
+	}
+	return c
 }
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeprecatedOriginsReplaceDefaults(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want []string
+	}{
+		{
+			env:  map[string]string{"CORS_ALLOWED_ORIGIN_PROD": "https://example.com"},
+			want: []string{defaultOrigin, "https://example.com", defaultOriginProdBackend},
+		},
+		{
+			env: map[string]string{
+				"CORS_ALLOWED_ORIGIN":              "https://example.com",
+				"CORS_ALLOWED_ORIGIN_PROD":         "https://example.com",
+				"CORS_ALLOWED_ORIGIN_PROD_BACKEND": "https://api.example.com",
+			},
+			want: []string{"https://example.com", "https://api.example.com"},
+		},
+		{
+			env: map[string]string{
+				"CORS_ALLOWED_ORIGINS": "https://example.com",
+				"CORS_ALLOWED_ORIGIN":  "https://app.example.com",
+			},
+			want: []string{"https://example.com", "https://app.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		cfg := Default()
+		lookup := func(name string) (string, bool) {
+			v, ok := tt.env[name]
+			return v, ok
+		}
+		if err := applyEnv(&cfg, lookup); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cfg.CORS.AllowedOrigins, tt.want) {
+			t.Errorf("env %v: got origins %v, want %v", tt.env, cfg.CORS.AllowedOrigins, tt.want)
+		}
+	}
+}
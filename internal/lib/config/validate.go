@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretLen is the minimal length of the JWT secret in prod, 32 bytes
+// match the output of the HS256 hash.
+const minSecretLen = 32
+
+// Validate checks every field and returns all the problems at once, named
+// by their key in the config file.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(validListenAddr(c.Addr), "addr", "invalid listen address %q, expected [host]:port", c.Addr)
+	check(validURL(c.CompleteAddr), "complete_addr", "invalid URL %q", c.CompleteAddr)
+	check(c.Env != "", "env", "is required")
+
+	check(validDBAddr(c.DB.Addr), "db.addr", "expected a postgres:// or sqlite:// address")
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns", "must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns", "must not be negative")
+	_, err := time.ParseDuration(c.DB.MaxIdleTime)
+	check(err == nil, "db.max_idle_time", "invalid duration %q", c.DB.MaxIdleTime)
+
+	check(c.Mail.Port == 0 || validPort(c.Mail.Port), "mail.port", "%d is not a valid port", c.Mail.Port)
+	if c.Mail.Addr != "" {
+		_, err := mail.ParseAddress(c.Mail.Addr)
+		check(err == nil, "mail.addr", "invalid email address %q", c.Mail.Addr)
+	}
+
+	check(c.Auth.Aud != "", "auth.aud", "is required")
+	check(c.Auth.Iat > 0, "auth.iat", "must be a positive duration")
+	check(c.Auth.RefreshExp > c.Auth.Iat, "auth.refresh_exp", "must be longer than auth.iat")
+	if len(c.Auth.Keys) == 0 {
+		check(c.Auth.Secret != "", "auth.secret", "is required without auth.keys")
+		if c.Env == "prod" {
+			check(
+				c.Auth.Secret != DefaultSecret && len(c.Auth.Secret) >= minSecretLen,
+				"auth.secret",
+				"must be at least %d characters and not the default in prod, or set auth.keys",
+				minSecretLen,
+			)
+		}
+	}
+	for i, kf := range c.Auth.Keys {
+		check(kf.ID != "" && kf.Path != "", fmt.Sprintf("auth.keys[%d]", i), "id and path are required")
+	}
+
+	for i, origin := range c.CORS.AllowedOrigins {
+		check(origin == "*" || validURL(origin), fmt.Sprintf("cors.allowed_origins[%d]", i), "invalid origin %q", origin)
+	}
+
+	check(c.ActiveWorkoutTTL > 0, "active_workout_ttl", "must be a positive duration")
+	check(c.SweepInterval > 0, "sweep_interval", "must be a positive duration")
+	check(c.ShutdownTimeout > 0, "shutdown_timeout", "must be a positive duration")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validListenAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	p, err := strconv.Atoi(port)
+	return err == nil && validPort(p)
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validDBAddr(addr string) bool {
+	if strings.HasPrefix(addr, "sqlite:") {
+		return strings.TrimPrefix(strings.TrimPrefix(addr, "sqlite:"), "//") != ""
+	}
+	u, err := url.Parse(addr)
+	return err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != ""
+}
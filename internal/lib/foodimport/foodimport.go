@@ -0,0 +1,154 @@
+// Package foodimport reads food database dumps and normalizes them to foods
+// with macronutrients per 100 grams.
+package foodimport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+const (
+	FormatOFFCSV   = "off-csv"
+	FormatOFFJSONL = "off-jsonl"
+	FormatUSDA     = "usda"
+
+	defaultBatchSize = 500
+	kJPerKcal        = 4.184
+
+	// the sizes of the foods name and barcode columns
+	maxNameLength    = 255
+	maxBarcodeLength = 64
+)
+
+type Upserter interface {
+	Upsert(context.Context, []store.Food) error
+}
+
+// Result counts the imported foods and the skipped records, which are
+// malformed, miss a barcode or a name, have impossible macronutrients or
+// are refused by the database.
+type Result struct {
+	Imported int
+	Skipped  int
+}
+
+// Import reads the dump at path in the given format and upserts its foods
+// by barcode in batches. When a batch fails its foods are upserted one by
+// one so that a bad record only skips itself. For FormatUSDA path is the directory of the
+// extracted FoodData Central branded foods CSV files.
+func Import(ctx context.Context, foods Upserter, format, path string, batchSize int) (Result, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var res Result
+	batch := make([]store.Food, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+
+		err := foods.Upsert(ctx, batch)
+		if err == nil {
+			res.Imported += len(batch)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+
+		imported := 0
+		for _, f := range batch {
+			if foods.Upsert(ctx, []store.Food{f}) == nil {
+				imported++
+			}
+		}
+		// every food failing on its own points at the database, not the records
+		if imported == 0 {
+			return err
+		}
+		res.Imported += imported
+		res.Skipped += len(batch) - imported
+		return nil
+	}
+	emit := func(f store.Food) error {
+		if !normalize(&f) {
+			res.Skipped++
+			return nil
+		}
+		batch = append(batch, f)
+		if len(batch) < batchSize {
+			return nil
+		}
+		return flush()
+	}
+
+	var err error
+	switch format {
+	case FormatOFFCSV:
+		err = readOFFCSV(path, emit)
+	case FormatOFFJSONL:
+		err = readOFFJSONL(path, emit)
+	case FormatUSDA:
+		err = readUSDA(path, emit)
+	default:
+		err = fmt.Errorf("unknown format %q, expected %s, %s or %s", format, FormatOFFCSV, FormatOFFJSONL, FormatUSDA)
+	}
+	if err != nil {
+		return res, err
+	}
+
+	return res, flush()
+}
+
+// normalize cleans up the food and reports whether it can be imported.
+// Calories are derived from the macronutrients when the dump has none.
+func normalize(f *store.Food) bool {
+	f.Name = strings.TrimSpace(strings.ReplaceAll(strings.ToValidUTF8(f.Name, ""), "\x00", ""))
+	if f.Barcode == nil || *f.Barcode == "" || len(*f.Barcode) > maxBarcodeLength || f.Name == "" {
+		return false
+	}
+	if utf8.RuneCountInString(f.Name) > maxNameLength {
+		f.Name = string([]rune(f.Name)[:maxNameLength])
+	}
+
+	if f.Proteins < 0 || f.Fats < 0 || f.Carbohydrates < 0 || f.Calories < 0 {
+		return false
+	}
+	// a little slack for rounding in the dumps
+	if f.Proteins+f.Fats+f.Carbohydrates > 101 {
+		return false
+	}
+	if f.Calories == 0 {
+		f.Calories = 4*f.Proteins + 9*f.Fats + 4*f.Carbohydrates
+	}
+	if f.Calories > 950 {
+		return false
+	}
+
+	return true
+}
+
+// barcode drops the leading zeros so the UPC-A and EAN-13 codes of the same
+// product match.
+func barcode(s string) *string {
+	s = strings.TrimLeft(strings.TrimSpace(s), "0")
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func parseFloat(s string) float32 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 32)
+	if err != nil {
+		return 0
+	}
+	return float32(v)
+}
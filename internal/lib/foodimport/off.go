@@ -0,0 +1,145 @@
+package foodimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// readOFFCSV reads the Open Food Facts CSV export, which is tab separated,
+// a comma separated file with the same columns works as well. Malformed
+// records are emitted empty so they are counted as skipped.
+func readOFFCSV(path string, emit func(store.Food) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	header, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	r := csv.NewReader(io.MultiReader(strings.NewReader(header), br))
+	if strings.Contains(header, "\t") {
+		r.Comma = '\t'
+	}
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	cols, err := r.Read()
+	if err != nil {
+		return err
+	}
+	idx := columns(cols)
+	if _, ok := idx["code"]; !ok {
+		return fmt.Errorf("%s: missing code column", path)
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := emit(store.Food{}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		get := func(col string) string {
+			i, ok := idx[col]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return rec[i]
+		}
+		food := store.Food{
+			Barcode:       barcode(get("code")),
+			Name:          get("product_name"),
+			Calories:      offCalories(parseFloat(get("energy-kcal_100g")), parseFloat(get("energy_100g"))),
+			Proteins:      parseFloat(get("proteins_100g")),
+			Fats:          parseFloat(get("fat_100g")),
+			Carbohydrates: parseFloat(get("carbohydrates_100g")),
+		}
+		if err := emit(food); err != nil {
+			return err
+		}
+	}
+}
+
+// readOFFJSONL reads the Open Food Facts JSONL export, one product per line.
+// Malformed lines are emitted empty so they are counted as skipped.
+func readOFFJSONL(path string, emit func(store.Food) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	type product struct {
+		Code        json.RawMessage            `json:"code"`
+		ProductName string                     `json:"product_name"`
+		Nutriments  map[string]json.RawMessage `json:"nutriments"`
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+	for sc.Scan() {
+		var p product
+		if err := json.Unmarshal(sc.Bytes(), &p); err != nil {
+			if err := emit(store.Food{}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		nutriment := func(key string) float32 {
+			return parseFloat(strings.Trim(string(p.Nutriments[key]), `"`))
+		}
+		food := store.Food{
+			Barcode:       barcode(strings.Trim(string(p.Code), `"`)),
+			Name:          p.ProductName,
+			Calories:      offCalories(nutriment("energy-kcal_100g"), nutriment("energy_100g")),
+			Proteins:      nutriment("proteins_100g"),
+			Fats:          nutriment("fat_100g"),
+			Carbohydrates: nutriment("carbohydrates_100g"),
+		}
+		if err := emit(food); err != nil {
+			return err
+		}
+	}
+
+	return sc.Err()
+}
+
+// offCalories prefers the kcal value and falls back to the energy in kJ.
+func offCalories(kcal, kJ float32) float32 {
+	if kcal > 0 {
+		return kcal
+	}
+	return kJ / kJPerKcal
+}
+
+func columns(header []string) map[string]int {
+	idx := make(map[string]int, len(header))
+	for i, c := range header {
+		idx[strings.TrimSpace(strings.TrimPrefix(c, "\ufeff"))] = i
+	}
+	return idx
+}
@@ -0,0 +1,128 @@
+package foodimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// FoodData Central nutrient ids, amounts are per 100 grams.
+const (
+	usdaEnergy        = 1008
+	usdaProtein       = 1003
+	usdaFat           = 1004
+	usdaCarbohydrates = 1005
+)
+
+// readUSDA reads the FoodData Central branded foods CSV files in dir:
+// food.csv for names, branded_food.csv for barcodes and food_nutrient.csv
+// for the macronutrients. Foods without a barcode are not read.
+func readUSDA(dir string, emit func(store.Food) error) error {
+	barcodes := make(map[int64]string)
+	err := readCSV(filepath.Join(dir, "branded_food.csv"), func(get func(string) string) error {
+		if code := get("gtin_upc"); code != "" {
+			barcodes[fdcID(get)] = code
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	foods := make(map[int64]*store.Food, len(barcodes))
+	err = readCSV(filepath.Join(dir, "food_nutrient.csv"), func(get func(string) string) error {
+		id := fdcID(get)
+		code, ok := barcodes[id]
+		if !ok {
+			return nil
+		}
+		f, ok := foods[id]
+		if !ok {
+			f = &store.Food{Barcode: barcode(code)}
+			foods[id] = f
+		}
+
+		nutrientID, _ := strconv.Atoi(get("nutrient_id"))
+		amount := parseFloat(get("amount"))
+		switch nutrientID {
+		case usdaEnergy:
+			f.Calories = amount
+		case usdaProtein:
+			f.Proteins = amount
+		case usdaFat:
+			f.Fats = amount
+		case usdaCarbohydrates:
+			f.Carbohydrates = amount
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return readCSV(filepath.Join(dir, "food.csv"), func(get func(string) string) error {
+		f, ok := foods[fdcID(get)]
+		if !ok {
+			return nil
+		}
+		f.Name = get("description")
+		return emit(*f)
+	})
+}
+
+// readCSV calls fn for every record of the comma separated file, get
+// returns the value of a column by its header name. Malformed records are
+// left out.
+func readCSV(path string, fn func(get func(string) string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	idx := columns(header)
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		get := func(col string) string {
+			i, ok := idx[col]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return rec[i]
+		}
+		if err := fn(get); err != nil {
+			return err
+		}
+	}
+}
+
+func fdcID(get func(string) string) int64 {
+	id, _ := strconv.ParseInt(get("fdc_id"), 10, 64)
+	return id
+}
@@ -2,53 +2,115 @@ package mailer
 
 import (
 	"bytes"
+	"embed"
+	"fmt"
 	"html/template"
-	"log"
 	"time"
 
-	"gopkg.in/gomail.v2"
-
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
 )
 
-const userVerificationTemplPath = "./internal/lib/mailer/templates/verify-email.html"
+const (
+	BackendSMTP   = "smtp"
+	BackendSpool  = "spool"
+	BackendMemory = "memory"
+)
+
+const (
+	userVerificationTempl = "templates/verify-email.html"
+	resetPasswordTempl    = "templates/reset-password.html"
+	confirmEmailTempl     = "templates/confirm-email.html"
+)
 
-func send(to []string, subject string, body string, emailCfg config.MailCfg) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", emailCfg.Addr)
-	m.SetHeader("To", to...)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+//go:embed templates
+var templates embed.FS
 
-	d := gomail.NewDialer(emailCfg.Host, emailCfg.Port, emailCfg.Addr, emailCfg.Password)
-	var err error
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
 
-	for i := 0; i < 3; i++ {
-		if err = d.DialAndSend(m); err == nil {
-			return nil
-		}
-		time.Sleep(time.Second * 5)
+// New returns the mailer backend selected in the config.
+func New(cfg config.MailCfg) (Mailer, error) {
+	switch cfg.Backend {
+	case BackendSMTP:
+		return NewSMTP(cfg), nil
+	case BackendSpool:
+		return NewSpool(cfg.SpoolDir, cfg.Addr)
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.Backend)
 	}
-	return nil
 }
 
-func SendVerifyUser(username, email, code string, emailCfg config.MailCfg) error {
-	var body bytes.Buffer
-	t, err := template.ParseFiles(userVerificationTemplPath)
+func SendVerifyUser(m Mailer, username, email, code string) error {
+	body, err := render(userVerificationTempl, struct {
+		Name string
+		Code string
+	}{Name: username, Code: code})
+	if err != nil {
+		return err
+	}
+
+	return m.Send(Message{
+		To:      []string{email},
+		Subject: "User Verification",
+		Body:    body,
+	})
+}
+
+func SendResetPassword(m Mailer, username, email, code string, exp time.Duration) error {
+	body, err := render(resetPasswordTempl, struct {
+		Name string
+		Code string
+		Exp  time.Duration
+	}{Name: username, Code: code, Exp: exp})
 	if err != nil {
-		log.Fatal("cannot to parse email template")
+		return err
 	}
-	t.Execute(
-		&body, struct {
-			Name string
-			Code string
-		}{Name: username, Code: code},
-	)
-	bodyStr := body.String()
-	err = send([]string{email}, "User Verification", bodyStr, emailCfg)
+
+	return m.Send(Message{
+		To:      []string{email},
+		Subject: "Password Reset",
+		Body:    body,
+	})
+}
+
+// SendConfirmEmail sends the code confirming an email change to the new
+// address.
+func SendConfirmEmail(m Mailer, username, email, code string, exp time.Duration) error {
+	body, err := render(confirmEmailTempl, struct {
+		Name string
+		Code string
+		Exp  time.Duration
+	}{Name: username, Code: code, Exp: exp})
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return m.Send(Message{
+		To:      []string{email},
+		Subject: "Email Change Confirmation",
+		Body:    body,
+	})
+}
+
+func render(name string, data any) (string, error) {
+	t, err := template.ParseFS(templates, name)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse email template %s: %w", name, err)
+	}
+
+	var body bytes.Buffer
+	if err := t.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("cannot execute email template %s: %w", name, err)
+	}
+
+	return body.String(), nil
 }
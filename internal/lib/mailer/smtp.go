@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/gomail.v2"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/config"
+)
+
+const (
+	smtpRetries    = 3
+	smtpRetryDelay = time.Second * 5
+)
+
+type SMTPMailer struct {
+	from   string
+	dialer *gomail.Dialer
+}
+
+func NewSMTP(cfg config.MailCfg) *SMTPMailer {
+	return &SMTPMailer{
+		from:   cfg.Addr,
+		dialer: gomail.NewDialer(cfg.Host, cfg.Port, cfg.Addr, cfg.Password),
+	}
+}
+
+func (s *SMTPMailer) Send(msg Message) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.from)
+	m.SetHeader("To", msg.To...)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/html", msg.Body)
+
+	var err error
+	for i := 0; i < smtpRetries; i++ {
+		if err = s.dialer.DialAndSend(m); err == nil {
+			return nil
+		}
+		if i < smtpRetries-1 {
+			time.Sleep(smtpRetryDelay)
+		}
+	}
+
+	return fmt.Errorf("cannot send email after %d attempts: %w", smtpRetries, err)
+}
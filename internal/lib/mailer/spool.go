@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SpoolMailer writes every message as an .eml file into a local directory,
+// so emails can be read during development without an SMTP server.
+type SpoolMailer struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+func NewSpool(dir, from string) (*SpoolMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail spool directory is not set")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &SpoolMailer{dir: dir, from: from}, nil
+}
+
+func (s *SpoolMailer) Send(msg Message) error {
+	now := time.Now()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	name := fmt.Sprintf("%d-%d.eml", now.UnixNano(), s.seq.Add(1))
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+
+	// rename so readers of the directory never see a partially written file
+	return os.Rename(tmp, filepath.Join(s.dir, name))
+}
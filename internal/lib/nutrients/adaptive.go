@@ -0,0 +1,174 @@
+package nutrients
+
+import (
+	"math"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+const (
+	// AdaptiveWindow is the period the actual TDEE is estimated over.
+	AdaptiveWindow = 28 * 24 * time.Hour
+	// AdaptiveWarmup is the weight history read before the window to seed
+	// the trend.
+	AdaptiveWarmup = 14 * 24 * time.Hour
+
+	// trendSmoothing is the weight of a new weigh-in in the moving average.
+	trendSmoothing = 0.1
+	kcalPerKg      = 7700
+	// maxWeeklyAdjustment caps how much the suggestion moves per week.
+	maxWeeklyAdjustment = 250
+
+	minWeighIns   = 4
+	minLoggedDays = 10
+	minTrendDays  = 14
+
+	StatusInsufficientData = "insufficient_data"
+	StatusOnTrack          = "on_track"
+	StatusPlateau          = "plateau"
+	StatusTooFast          = "too_fast"
+	StatusTooSlow          = "too_slow"
+	StatusGoalReached      = "goal_reached"
+)
+
+// Adaptive is the calories suggestion estimated from the weight trend and the
+// logged intake of the last full weeks, it changes once a week. Weights are
+// in kg and weekly changes in kg per week.
+type Adaptive struct {
+	Status             string    `json:"status"`
+	WindowStart        time.Time `json:"window_start"`
+	WindowEnd          time.Time `json:"window_end"`
+	NextReview         time.Time `json:"next_review"`
+	WeighIns           int       `json:"weigh_ins"`
+	LoggedDays         int       `json:"logged_days"`
+	TrendStart         float32   `json:"trend_start,omitempty"`
+	TrendEnd           float32   `json:"trend_end,omitempty"`
+	WeeklyChange       float32   `json:"weekly_change"`
+	TargetWeeklyChange float32   `json:"target_weekly_change"`
+	AverageIntake      float32   `json:"average_intake,omitempty"`
+	EstimatedTDEE      float32   `json:"estimated_tdee,omitempty"`
+	SuggestedCalories  float32   `json:"suggested_calories,omitempty"`
+	Adjustment         float32   `json:"adjustment"`
+}
+
+// AdaptiveWindowAt returns the window of the last four full weeks before
+// now, weeks start on Monday.
+func AdaptiveWindowAt(now time.Time) (start, end time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	end = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return end.Add(-AdaptiveWindow), end
+}
+
+// Adapt compares the weight trend with the goal of the user. weights must
+// be sorted oldest first and start AdaptiveWarmup before the window, intake
+// holds the logged calories per day of the window.
+func Adapt(
+	ua store.UserAttributes,
+	goal Calculation,
+	weights []store.UserWeightByDate,
+	intake []store.DailyIntake,
+	now time.Time,
+) Adaptive {
+	start, end := AdaptiveWindowAt(now)
+	a := Adaptive{
+		Status:             StatusInsufficientData,
+		WindowStart:        start,
+		WindowEnd:          end,
+		NextReview:         end.AddDate(0, 0, 7),
+		LoggedDays:         len(intake),
+		TargetWeeklyChange: targetWeeklyChange(ua),
+	}
+
+	var trend float32
+	var first, last time.Time
+	for i, w := range weights {
+		date, err := time.Parse(time.RFC3339, w.Date)
+		if err != nil {
+			continue
+		}
+		if i == 0 {
+			trend = w.Weight
+		} else {
+			trend += trendSmoothing * (w.Weight - trend)
+		}
+		if date.Before(start) {
+			continue
+		}
+		if a.WeighIns == 0 {
+			a.TrendStart, first = trend, date
+		}
+		a.WeighIns++
+		a.TrendEnd, last = trend, date
+	}
+
+	days := last.Sub(first).Hours() / 24
+	if a.WeighIns < minWeighIns || days < minTrendDays || a.LoggedDays < minLoggedDays {
+		return a
+	}
+
+	var total float32
+	for _, di := range intake {
+		total += di.Calories
+	}
+	a.AverageIntake = total / float32(a.LoggedDays)
+
+	dailyChange := (a.TrendEnd - a.TrendStart) / float32(days)
+	a.WeeklyChange = dailyChange * 7
+	a.EstimatedTDEE = a.AverageIntake - dailyChange*kcalPerKg
+
+	suggested := a.EstimatedTDEE + a.TargetWeeklyChange/7*kcalPerKg
+	adjustment := suggested - goal.Calories
+	adjustment = float32(math.Max(-maxWeeklyAdjustment, math.Min(maxWeeklyAdjustment, float64(adjustment))))
+	// never suggest eating below the resting needs
+	a.SuggestedCalories = max(goal.Calories+adjustment, goal.BMR)
+	a.Adjustment = a.SuggestedCalories - goal.Calories
+
+	a.Status = status(ua, a)
+	return a
+}
+
+// targetWeeklyChange is a sustainable pace for the goal: losing 0.5% and
+// gaining 0.25% of the body weight per week.
+func targetWeeklyChange(ua store.UserAttributes) float32 {
+	switch ua.Goal {
+	case "lose":
+		return -0.005 * ua.Weight
+	case "gain":
+		return 0.0025 * ua.Weight
+	default:
+		return 0
+	}
+}
+
+func status(ua store.UserAttributes, a Adaptive) string {
+	switch ua.Goal {
+	case "lose":
+		switch {
+		case a.TrendEnd <= ua.WeightGoal:
+			return StatusGoalReached
+		case a.WeeklyChange < -0.01*a.TrendEnd:
+			return StatusTooFast
+		case a.WeeklyChange > -0.1:
+			return StatusPlateau
+		case a.WeeklyChange > a.TargetWeeklyChange/2:
+			return StatusTooSlow
+		}
+	case "gain":
+		switch {
+		case a.TrendEnd >= ua.WeightGoal:
+			return StatusGoalReached
+		case a.WeeklyChange > 0.005*a.TrendEnd:
+			return StatusTooFast
+		case a.WeeklyChange < 0.05:
+			return StatusPlateau
+		case a.WeeklyChange < a.TargetWeeklyChange/2:
+			return StatusTooSlow
+		}
+	default:
+		if math.Abs(float64(a.WeeklyChange)) > 0.0025*float64(a.TrendEnd) {
+			return StatusTooFast
+		}
+	}
+	return StatusOnTrack
+}
@@ -9,53 +9,148 @@ type UserNutrientsGoal struct {
 	Carbohydrats float32
 }
 
+// Calculation is the daily goal together with the values it was derived
+// from: BMR from the formula, TDEE from the activity factor and the calories
+// adjustment for the goal, negative for a deficit.
+type Calculation struct {
+	UserNutrientsGoal
+	Formula        string   `json:"formula"`
+	ActivityLevel  string   `json:"activity_level"`
+	ActivityFactor float32  `json:"activity_factor"`
+	BMR            float32  `json:"bmr"`
+	TDEE           float32  `json:"tdee"`
+	Adjustment     float32  `json:"adjustment"`
+	LeanBodyMass   *float32 `json:"lean_body_mass,omitempty"`
+	// Split is the share of the calories from every macronutrient
+	Split    store.MacroRatios `json:"split"`
+	Adaptive *Adaptive         `json:"adaptive,omitempty"`
+}
+
+var activityFactors = map[string]float32{
+	store.ActivitySedentary:  1.2,
+	store.ActivityLight:      1.375,
+	store.ActivityModerate:   1.55,
+	store.ActivityActive:     1.725,
+	store.ActivityVeryActive: 1.9,
+}
+
+// goalAdjustment is the daily calories deficit or surplus for a goal.
+var goalAdjustment = map[string]float32{
+	"lose":     -500,
+	"gain":     500,
+	"maintain": 0,
+}
+
 func CalculateMacronutrients(userAttr store.UserAttributes) UserNutrientsGoal {
-	// Calculate BMR using Mifflin-St Jeor Equation
-	var BMR float32
-	if userAttr.IsMale {
-		BMR = 10*userAttr.Weight + 6.25*float32(userAttr.Height) - 5*float32(userAttr.Age) + 5
-	} else {
-		BMR = 10*userAttr.Weight + 6.25*float32(userAttr.Height) - 5*float32(userAttr.Age) - 161
-	}
+	return Calculate(userAttr).UserNutrientsGoal
+}
 
-	// Assuming a moderate activity level (TDEE multiplier ~1.55)
-	TDEE := BMR * 1.55
+// Calculate computes the daily goal of the user. Katch-McArdle falls back to
+// Mifflin-St Jeor when the body fat is unknown.
+func Calculate(userAttr store.UserAttributes) Calculation {
+	c := Calculation{
+		Formula:       userAttr.Formula,
+		ActivityLevel: userAttr.ActivityLevel,
+	}
 
-	// Adjust TDEE based on user's goal
-	switch userAttr.Goal {
-	case "lose":
-		TDEE -= 500 // To lose weight, we aim for a 500-calorie deficit.
-	case "gain":
-		TDEE += 500 // To gain weight, we aim for a 500-calorie surplus.
-	case "maintain":
-		// No change for maintenance.
+	switch {
+	case c.Formula == store.FormulaKatchMcArdle && userAttr.BodyFat != nil:
+		lbm := userAttr.Weight * (1 - *userAttr.BodyFat/100)
+		c.LeanBodyMass = &lbm
+		c.BMR = 370 + 21.6*lbm
+	case c.Formula == store.FormulaHarrisBenedict:
+		c.BMR = harrisBenedict(userAttr)
+	default:
+		c.Formula = store.FormulaMifflin
+		c.BMR = mifflin(userAttr)
 	}
 
-	// Set protein intake (grams per kg of body weight)
-	var proteinPerKg float32 = 2.0 // Setting a baseline protein intake.
-	if userAttr.Goal == "gain" {
-		proteinPerKg = 2.2
-	} else if userAttr.Goal == "lose" {
-		proteinPerKg = 1.8
+	factor, ok := activityFactors[c.ActivityLevel]
+	if !ok {
+		c.ActivityLevel = store.ActivityModerate
+		factor = activityFactors[store.ActivityModerate]
 	}
-	proteinGrams := proteinPerKg * userAttr.Weight
+	c.ActivityFactor = factor
+	c.TDEE = c.BMR * factor
 
-	// Calculate protein calories (1g of protein = 4 calories)
-	proteinCalories := proteinGrams * 4
+	c.Adjustment = goalAdjustment[userAttr.Goal]
+	calories := c.TDEE + c.Adjustment
 
-	// Set fat intake (~30% of total TDEE)
-	fatCalories := TDEE * 0.30
-	fatGrams := fatCalories / 9 // 1g of fat = 9 calories
+	var proteinGrams, fatGrams, carbGrams float32
+	if r := userAttr.MacroRatios; r != nil {
+		proteinGrams = calories * r.Proteins / 4
+		fatGrams = calories * r.Fats / 9
+		carbGrams = calories * r.Carbohydrates / 4
+	} else {
+		// Set protein intake (grams per kg of body weight)
+		var proteinPerKg float32 = 2.0 // Setting a baseline protein intake.
+		if userAttr.Goal == "gain" {
+			proteinPerKg = 2.2
+		} else if userAttr.Goal == "lose" {
+			proteinPerKg = 1.8
+		}
+		proteinGrams = proteinPerKg * userAttr.Weight
 
-	// Remaining calories for carbohydrates
-	remainingCalories := TDEE - (proteinCalories + fatCalories)
-	carbGrams := remainingCalories / 4 // 1g of carbohydrates = 4 calories
+		// Set fat intake (~30% of total calories), 1g of fat = 9 calories
+		fatGrams = calories * 0.30 / 9
 
-	// Return the calculated macronutrients
-	return UserNutrientsGoal{
-		Calories:     TDEE,
+		// Remaining calories for carbohydrates, 1g = 4 calories
+		carbGrams = (calories - (proteinGrams*4 + fatGrams*9)) / 4
+	}
+
+	c.UserNutrientsGoal = UserNutrientsGoal{
+		Calories:     calories,
 		Proteins:     proteinGrams,
 		Fats:         fatGrams,
 		Carbohydrats: carbGrams,
 	}
+	if calories > 0 {
+		c.Split = store.MacroRatios{
+			Proteins:      proteinGrams * 4 / calories,
+			Fats:          fatGrams * 9 / calories,
+			Carbohydrates: carbGrams * 4 / calories,
+		}
+	}
+
+	return c
+}
+
+// mifflin is the Mifflin-St Jeor equation.
+func mifflin(ua store.UserAttributes) float32 {
+	bmr := 10*ua.Weight + 6.25*float32(ua.Height) - 5*float32(ua.Age)
+	if ua.IsMale {
+		return bmr + 5
+	}
+	return bmr - 161
+}
+
+// harrisBenedict is the Harris-Benedict equation revised by Roza and Shizgal.
+func harrisBenedict(ua store.UserAttributes) float32 {
+	if ua.IsMale {
+		return 88.362 + 13.397*ua.Weight + 4.799*float32(ua.Height) - 5.677*float32(ua.Age)
+	}
+	return 447.593 + 9.247*ua.Weight + 3.098*float32(ua.Height) - 4.330*float32(ua.Age)
+}
+
+// Consumed sums the macronutrients of the meals.
+func Consumed(meals []store.Meal) UserNutrientsGoal {
+	var n UserNutrientsGoal
+	for _, m := range meals {
+		n.Calories += m.Calories
+		n.Proteins += m.Proteins
+		n.Fats += m.Fats
+		n.Carbohydrats += m.Carbohydrates
+	}
+	return n
+}
+
+// Sub returns what is left of the goal after the consumed nutrients, it is
+// negative when the goal is exceeded.
+func (g UserNutrientsGoal) Sub(consumed UserNutrientsGoal) UserNutrientsGoal {
+	return UserNutrientsGoal{
+		Calories:     g.Calories - consumed.Calories,
+		Proteins:     g.Proteins - consumed.Proteins,
+		Fats:         g.Fats - consumed.Fats,
+		Carbohydrats: g.Carbohydrats - consumed.Carbohydrats,
+	}
 }
@@ -0,0 +1,52 @@
+package sweeper
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// Sweeper periodically removes active workouts nobody updated for longer
+// than the ttl.
+type Sweeper struct {
+	store    store.Storage
+	log      *zap.SugaredLogger
+	interval time.Duration
+	ttl      time.Duration
+}
+
+func New(store store.Storage, log *zap.SugaredLogger, interval, ttl time.Duration) *Sweeper {
+	return &Sweeper{store, log, interval, ttl}
+}
+
+// Run sweeps every interval until ctx is done.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeDuration)
+	defer cancel()
+
+	n, err := s.store.ActiveWorkouts.DeleteStale(ctx, time.Now().Add(-s.ttl))
+	if err != nil {
+		s.log.Errorw("cannot expire active workouts", "error", err)
+		return
+	}
+	if n > 0 {
+		s.log.Infow("expired active workouts", "count", n)
+	}
+}
@@ -0,0 +1,53 @@
+// Package trigram computes the trigram similarity of pg_trgm, so the stores
+// without the extension rank food searches the same way Postgres does.
+package trigram
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Threshold is the default pg_trgm.similarity_threshold used by the %
+// operator.
+const Threshold = 0.3
+
+// Similarity returns how many trigrams a and b share, from 0 for none to 1
+// for the same set of trigrams.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// Similar reports whether a and b are similar enough to match the %
+// operator.
+func Similar(a, b string) bool {
+	return Similarity(a, b) >= Threshold
+}
+
+// trigrams splits s into lower cased alphanumeric words, each padded with two
+// spaces in front and one behind, and returns the set of their trigrams.
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+
+	return set
+}
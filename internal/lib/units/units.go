@@ -0,0 +1,175 @@
+// Package units converts between the metric values the store keeps and the
+// unit system of a user. Imperial weights are in lb, lengths in inches and
+// heights in whole inches, not feet and inches: 5 ft 10 in is 70.
+package units
+
+import (
+	"math"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/nutrients"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+
+	kgPerLb   = 0.45359237
+	cmPerInch = 2.54
+)
+
+func Valid(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+// Weight converts kg to the system.
+func Weight(kg float32, system string) float32 {
+	if system != Imperial {
+		return kg
+	}
+	return round(kg / kgPerLb)
+}
+
+// ToKg converts a weight in the system to kg.
+func ToKg(w float32, system string) float32 {
+	if system != Imperial {
+		return w
+	}
+	return w * kgPerLb
+}
+
+// Length converts cm to the system.
+func Length(cm float32, system string) float32 {
+	if system != Imperial {
+		return cm
+	}
+	return round(cm / cmPerInch)
+}
+
+// ToCm converts a length in the system to cm.
+func ToCm(l float32, system string) float32 {
+	if system != Imperial {
+		return l
+	}
+	return l * cmPerInch
+}
+
+// Height converts a height in cm to the system, imperial heights are whole
+// inches, 178 cm is 70 rather than 5 ft 10 in.
+func Height(cm int, system string) int {
+	if system != Imperial {
+		return cm
+	}
+	return int(math.Round(float64(cm) / cmPerInch))
+}
+
+// HeightToCm converts a height in the system to cm, imperial heights are
+// given in whole inches.
+func HeightToCm(h int, system string) int {
+	if system != Imperial {
+		return h
+	}
+	return int(math.Round(float64(h) * cmPerInch))
+}
+
+func WeightUnit(system string) string {
+	if system == Imperial {
+		return "lb"
+	}
+	return "kg"
+}
+
+func LengthUnit(system string) string {
+	if system == Imperial {
+		return "in"
+	}
+	return "cm"
+}
+
+// round keeps two decimals so converted values do not look off.
+func round(v float32) float32 {
+	return float32(math.Round(float64(v)*100) / 100)
+}
+
+func UserAttributes(ua *store.UserAttributes, system string) {
+	ua.Height = Height(ua.Height, system)
+	ua.Weight = Weight(ua.Weight, system)
+	ua.WeightGoal = Weight(ua.WeightGoal, system)
+}
+
+func Weights(weights []store.UserWeightByDate, system string) {
+	for i := range weights {
+		weights[i].Weight = Weight(weights[i].Weight, system)
+	}
+}
+
+func Sets(sets []store.WorkoutSet, system string) {
+	for i := range sets {
+		sets[i].Weight = Weight(sets[i].Weight, system)
+	}
+}
+
+func FinishedWorkout(fn *store.FinishedWorkout, system string) {
+	Sets(fn.Sets, system)
+	Records(fn.NewRecords, system)
+}
+
+// Records converts the weight and estimated 1RM records, reps and durations
+// have no unit.
+func Records(records []store.PersonalRecord, system string) {
+	for i := range records {
+		pr := &records[i]
+		if pr.Kind != store.RecordWeight && pr.Kind != store.RecordEstimated1RM {
+			continue
+		}
+		pr.Value = Weight(pr.Value, system)
+		if pr.Previous != nil {
+			previous := Weight(*pr.Previous, system)
+			pr.Previous = &previous
+		}
+	}
+}
+
+func Progress(progress []store.ExerciseProgress, system string) {
+	for i := range progress {
+		progress[i].MaxWeight = Weight(progress[i].MaxWeight, system)
+		progress[i].Estimated1RM = Weight(progress[i].Estimated1RM, system)
+		progress[i].Volume = Weight(progress[i].Volume, system)
+	}
+}
+
+func Stats(st *store.Stats, system string) {
+	Weights(st.Weight, system)
+	st.WeightChange = Weight(st.WeightChange, system)
+	for i := range st.Muscles {
+		st.Muscles[i].Volume = Weight(st.Muscles[i].Volume, system)
+	}
+}
+
+// Measurements converts the lengths, body fat and heart rate keep their
+// units.
+func Measurements(measurements []store.Measurement, system string) {
+	for i := range measurements {
+		m := &measurements[i]
+		if m.Unit != LengthUnit(Metric) {
+			continue
+		}
+		m.Value = Length(m.Value, system)
+		m.Unit = LengthUnit(system)
+	}
+}
+
+// Calculation converts the body weights of the nutrients goal, calories and
+// macronutrients grams are the same in both systems.
+func Calculation(c *nutrients.Calculation, system string) {
+	if c.LeanBodyMass != nil {
+		lbm := Weight(*c.LeanBodyMass, system)
+		c.LeanBodyMass = &lbm
+	}
+	if a := c.Adaptive; a != nil {
+		a.TrendStart = Weight(a.TrendStart, system)
+		a.TrendEnd = Weight(a.TrendEnd, system)
+		a.WeeklyChange = Weight(a.WeeklyChange, system)
+		a.TargetWeeklyChange = Weight(a.TargetWeeklyChange, system)
+	}
+}
@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Registry runs the background goroutines of the server, like the sweeper
+// and the email sends, so they can be stopped and awaited on shutdown.
+type Registry struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	log    *zap.SugaredLogger
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+	stopped bool
+}
+
+func New(log *zap.SugaredLogger) *Registry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{
+		ctx:     ctx,
+		cancel:  cancel,
+		log:     log,
+		running: make(map[string]int),
+	}
+}
+
+// Go runs fn in a new goroutine. The context passed to fn is canceled by
+// Stop, long running workers must return once it is done. Once Stop was
+// called Go drops fn.
+func (r *Registry) Go(name string, fn func(context.Context)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		r.log.Warnw("worker started after shutdown, dropping it", "worker", name)
+		return
+	}
+
+	r.running[name]++
+	r.wg.Add(1)
+	go func() {
+		defer r.done(name)
+		fn(r.ctx)
+	}()
+}
+
+// Stop cancels the context of the workers and waits until all of them
+// returned. When ctx is done first it returns an error naming the workers
+// still running.
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers still running: %v: %w", r.pending(), ctx.Err())
+	}
+}
+
+func (r *Registry) done(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running[name]--
+	if r.running[name] == 0 {
+		delete(r.running, name)
+	}
+	r.wg.Done()
+}
+
+func (r *Registry) pending() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.running))
+	for name := range r.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ActiveWorkout is a workout session which is still in progress. A user has at
+// most one of them, PausedDuration is the total paused time in seconds.
+type ActiveWorkout struct {
+	ID             int64        `json:"id"`
+	UserID         int64        `json:"user_id"`
+	WorkoutID      int64        `json:"workout_id"`
+	StartedAt      time.Time    `json:"started_at"`
+	PausedAt       *time.Time   `json:"paused_at"`
+	PausedDuration int          `json:"paused_duration"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Sets           []WorkoutSet `json:"sets"`
+}
+
+func (aw *ActiveWorkout) IsPaused() bool {
+	return aw.PausedAt != nil
+}
+
+// Duration returns the time spent training until now, without pauses.
+func (aw *ActiveWorkout) Duration(now time.Time) time.Duration {
+	d := now.Sub(aw.StartedAt) - time.Duration(aw.PausedDuration)*time.Second
+	if aw.PausedAt != nil {
+		d -= now.Sub(*aw.PausedAt)
+	}
+	return max(d, 0)
+}
+
+type ActiveWorkoutsStore struct {
+	db *sql.DB
+}
+
+// Start begins a new session. It returns ErrConflict when the user already has
+// one and ErrNotFound when the workout does not exist.
+func (s *ActiveWorkoutsStore) Start(ctx context.Context, aw *ActiveWorkout) error {
+	query := `
+		INSERT INTO active_workouts (user_id, workout_id) VALUES ($1, $2)
+		RETURNING id, started_at, updated_at
+	`
+	err := s.db.QueryRowContext(ctx, query, aw.UserID, aw.WorkoutID).
+		Scan(&aw.ID, &aw.StartedAt, &aw.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return fkViolationAsNotFound(err)
+	}
+
+	aw.Sets = make([]WorkoutSet, 0)
+	return nil
+}
+
+// GetByUserID returns the session of the user together with its sets.
+func (s *ActiveWorkoutsStore) GetByUserID(ctx context.Context, userID int64) (*ActiveWorkout, error) {
+	query := `
+		SELECT id, user_id, workout_id, started_at, paused_at, paused_duration, updated_at
+		FROM active_workouts
+		WHERE user_id = $1
+	`
+	aw := &ActiveWorkout{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&aw.ID,
+		&aw.UserID,
+		&aw.WorkoutID,
+		&aw.StartedAt,
+		&aw.PausedAt,
+		&aw.PausedDuration,
+		&aw.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	sets, err := s.getSets(ctx, aw.ID)
+	if err != nil {
+		return nil, err
+	}
+	aw.Sets = sets
+
+	return aw, nil
+}
+
+// Pause returns ErrConflict when the session is already paused.
+func (s *ActiveWorkoutsStore) Pause(ctx context.Context, id int64) error {
+	query := `
+		UPDATE active_workouts SET paused_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND paused_at IS NULL
+	`
+	if err := execAffectingOne(ctx, s.db, query, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// Resume returns ErrConflict when the session is not paused.
+func (s *ActiveWorkoutsStore) Resume(ctx context.Context, id int64) error {
+	query := `
+		UPDATE active_workouts
+		SET paused_duration = paused_duration + EXTRACT(EPOCH FROM NOW() - paused_at)::int,
+		  paused_at = NULL,
+		  updated_at = NOW()
+		WHERE id = $1 AND paused_at IS NOT NULL
+	`
+	if err := execAffectingOne(ctx, s.db, query, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// AddSet appends the set to the session, numbering it after the previous sets
+// of the same exercise.
+func (s *ActiveWorkoutsStore) AddSet(ctx context.Context, activeWorkoutID int64, ws *WorkoutSet) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO active_workout_sets (active_workout_id, exercise_id, set_number, reps, weight, duration, rpe)
+			VALUES (
+			  $1,
+			  $2,
+			  (SELECT COALESCE(MAX(set_number), 0) + 1 FROM active_workout_sets WHERE active_workout_id = $1 AND exercise_id = $2),
+			  $3, $4, $5, $6
+			)
+			RETURNING id, set_number
+		`
+		err := tx.QueryRowContext(ctx, query, activeWorkoutID, ws.ExerciseID, ws.Reps, ws.Weight, ws.Duration, ws.RPE).
+			Scan(&ws.ID, &ws.SetNumber)
+		if err != nil {
+			return fkViolationAsNotFound(err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE active_workouts SET updated_at = NOW() WHERE id = $1`, activeWorkoutID)
+		return err
+	})
+}
+
+// Finish removes the session and saves it as a finished workout ending at
+// endedAt. The session is removed first so that when it is finished twice
+// concurrently, only one finished workout is saved and the other call gets
+// ErrNotFound.
+func (s *ActiveWorkoutsStore) Finish(
+	ctx context.Context,
+	aw *ActiveWorkout,
+	endedAt time.Time,
+) (*FinishedWorkout, error) {
+	fn := &FinishedWorkout{
+		UserID:    aw.UserID,
+		WorkoutID: aw.WorkoutID,
+		StartedAt: aw.StartedAt,
+		EndedAt:   endedAt,
+		Duration:  int(aw.Duration(endedAt).Seconds()),
+		Sets:      aw.Sets,
+	}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := execAffectingOne(ctx, tx, `DELETE FROM active_workouts WHERE id = $1`, aw.ID)
+		if err != nil {
+			return err
+		}
+
+		return (FinishedWorkoutsStore{s.db}).create(ctx, tx, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+func (s *ActiveWorkoutsStore) Delete(ctx context.Context, id int64) error {
+	query := `
+		DELETE FROM active_workouts WHERE id = $1
+	`
+	return execAffectingOne(ctx, s.db, query, id)
+}
+
+// DeleteStale removes sessions which were not updated since before and
+// returns how many were removed.
+func (s *ActiveWorkoutsStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM active_workouts WHERE updated_at < $1
+	`
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *ActiveWorkoutsStore) getSets(ctx context.Context, activeWorkoutID int64) ([]WorkoutSet, error) {
+	query := `
+		SELECT id, exercise_id, set_number, reps, weight, duration, rpe
+		FROM active_workout_sets
+		WHERE active_workout_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query, activeWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]WorkoutSet, 0)
+	for rows.Next() {
+		var ws WorkoutSet
+		err := rows.Scan(
+			&ws.ID,
+			&ws.ExerciseID,
+			&ws.SetNumber,
+			&ws.Reps,
+			&ws.Weight,
+			&ws.Duration,
+			&ws.RPE,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, ws)
+	}
+
+	return sets, rows.Err()
+}
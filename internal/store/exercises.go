@@ -22,6 +22,7 @@ type Exercise struct {
 	CreatedAt    string   `json:"created_at"`
 	Muscles      []string `json:"muscles"`
 	Likes        int      `json:"like"`
+	IsHidden     bool     `json:"is_hidden"`
 }
 
 type ExerciseStore struct {
@@ -37,7 +38,7 @@ func (s *ExerciseStore) GetAll(
     FROM exercises 
 		LEFT JOIN exercise_likes el ON exercises.id = el.exercise_id
     WHERE (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%') AND 
-(muscles @> $2 OR $2 = '{}')
+(muscles @> $2 OR $2 = '{}') AND NOT is_hidden
 		GROUP BY id
     ORDER BY likes ` + fq.Sort + `
     LIMIT $3 OFFSET $4
@@ -92,14 +93,14 @@ func (s *ExerciseStore) Create(ctx context.Context, e *Exercise) error {
 
 func (s *ExerciseStore) GetByID(ctx context.Context, id int64) (*Exercise, error) {
 	query := `
-		SELECT user_id, name, description, is_duration, duration, tutorial_link, muscles, created_at FROM exercises WHERE id = $1
+		SELECT user_id, name, description, is_duration, duration, tutorial_link, muscles, created_at, is_hidden FROM exercises WHERE id = $1
 	`
 	e := &Exercise{
 		ID: id,
 	}
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&e.UserID, &e.Name, &e.Description, &e.IsDuration, &e.Duration, &e.TutorialLink, pq.Array(&e.Muscles), &e.CreatedAt,
+		&e.UserID, &e.Name, &e.Description, &e.IsDuration, &e.Duration, &e.TutorialLink, pq.Array(&e.Muscles), &e.CreatedAt, &e.IsHidden,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -120,7 +121,7 @@ func (s *ExerciseStore) GetUsersExercises(
 		SELECT id, exercises.user_id, name, description, is_duration, duration, tutorial_link, created_at, muscles, COUNT(DISTINCT el.user_id) as likes
     FROM exercises 
 		LEFT JOIN exercise_likes el ON exercises.id = el.exercise_id
-    WHERE exercises.user_id = $1
+    WHERE exercises.user_id = $1 AND NOT is_hidden
 		GROUP BY id
     ORDER BY likes ` + fq.Sort + `
     LIMIT $2 OFFSET $3
@@ -158,3 +159,97 @@ func (s *ExerciseStore) GetUsersExercises(
 	}
 	return exercises, nil
 }
+
+func (s *ExerciseStore) Update(ctx context.Context, e *Exercise) error {
+	query := `
+		UPDATE exercises SET name = $1, description = $2, is_duration = $3, duration = $4, tutorial_link = $5, muscles = $6
+		WHERE id = $7
+	`
+	res, err := s.db.ExecContext(ctx, query, e.Name, e.Description, e.IsDuration, e.Duration, e.TutorialLink, pq.Array(e.Muscles), e.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the exercise and its likes. It returns ErrConflict when the
+// exercise is still part of a workout or of logged sets.
+func (s *ExerciseStore) Delete(ctx context.Context, id int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM exercise_likes WHERE exercise_id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM exercises WHERE id = $1`, id)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+				return ErrConflict
+			}
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetDependentWorkouts returns the workouts which include the exercise or
+// which have logged sets of it, finished or in progress.
+func (s *ExerciseStore) GetDependentWorkouts(ctx context.Context, id int64) ([]Workout, error) {
+	query := `
+		SELECT w.id, w.user_id, w.name, w.description, w.tutorial_link, w.created_at FROM workouts w
+		WHERE EXISTS (
+		  SELECT 1 FROM workout_exercises we WHERE we.workout_id = w.id AND we.exercise_id = $1
+		) OR EXISTS (
+		  SELECT 1 FROM finished_workout_sets fs
+		  JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		  WHERE fw.workout_id = w.id AND fs.exercise_id = $1
+		) OR EXISTS (
+		  SELECT 1 FROM active_workout_sets aws
+		  JOIN active_workouts aw ON aw.id = aws.active_workout_id
+		  WHERE aw.workout_id = w.id AND aws.exercise_id = $1
+		)
+		ORDER BY w.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := make([]Workout, 0)
+	for rows.Next() {
+		var w Workout
+		err := rows.Scan(&w.ID, &w.UserID, &w.Name, &w.Description, &w.TutorialLink, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+	return workouts, rows.Err()
+}
+
+func (s *ExerciseStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	query := `
+		UPDATE exercises SET is_hidden = $1 WHERE id = $2
+	`
+	return execAffectingOne(ctx, s.db, query, hidden, id)
+}
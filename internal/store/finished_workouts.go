@@ -3,13 +3,39 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
 )
 
 type FinishedWorkout struct {
-	Date      string
-	UserID    int64
-	WorkoutID int64
-	Duration  string
+	ID          int64        `json:"id"`
+	UserID      int64        `json:"user_id"`
+	WorkoutID   int64        `json:"workout_id"`
+	WorkoutName string       `json:"workout_name"`
+	StartedAt   time.Time    `json:"started_at"`
+	EndedAt     time.Time    `json:"ended_at"`
+	Duration    int          `json:"duration"`
+	Sets        []WorkoutSet `json:"sets"`
+	// NewRecords holds the personal records set by this workout, it is only
+	// filled when the workout is saved.
+	NewRecords []PersonalRecord `json:"new_records,omitempty"`
+}
+
+// WorkoutSet is a single logged set, Duration is in seconds and RPE is the
+// rate of perceived exertion from 1 to 10.
+type WorkoutSet struct {
+	ID                int64    `json:"id"`
+	FinishedWorkoutID int64    `json:"finished_workout_id,omitempty"`
+	ExerciseID        int64    `json:"exercise_id"`
+	SetNumber         int      `json:"set_number"`
+	Reps              int      `json:"reps"`
+	Weight            float32  `json:"weight"`
+	Duration          int      `json:"duration"`
+	RPE               *float32 `json:"rpe"`
 }
 
 type FinishedWorkoutsStore struct {
@@ -17,40 +43,183 @@ type FinishedWorkoutsStore struct {
 }
 
 func (s FinishedWorkoutsStore) Create(ctx context.Context, fn *FinishedWorkout) error {
-	query := `
-		INSERT INTO finished_workouts (user_id, workout_id, duration) VALUES ($1,$2,$3)
-	`
-	_, err := s.db.ExecContext(ctx, query, fn.UserID, fn.WorkoutID, fn.Duration)
-	if err != nil {
-		return err
-	}
-	return nil
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.create(ctx, tx, fn)
+	})
 }
 
 func (s FinishedWorkoutsStore) GetAll(
 	ctx context.Context,
+	fq pagination.PaginatedQuery,
 	userID int64,
-) ([]FinishedWorkout, error) {
+) ([]FinishedWorkout, int, error) {
 	query := `
-		SELECT date, user_id, workout_id, duration FROM finished_workouts
-		WHERE user_id = $1
+		SELECT fw.id, fw.user_id, fw.workout_id, w.name, fw.started_at, fw.ended_at, fw.duration,
+		  COUNT(*) OVER() AS total_count
+		FROM finished_workouts fw
+		JOIN workouts w ON w.id = fw.workout_id
+		WHERE fw.user_id = $1
+		ORDER BY fw.ended_at ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
 	`
-	rows, err := s.db.QueryContext(ctx, query, userID)
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	defer rows.Close()
 
+	var totalCount int
 	finisedWorkouts := make([]FinishedWorkout, 0)
 	for rows.Next() {
 		var fn FinishedWorkout
-		rows.Scan(
-			&fn.Date,
+		err := rows.Scan(
+			&fn.ID,
 			&fn.UserID,
 			&fn.WorkoutID,
+			&fn.WorkoutName,
+			&fn.StartedAt,
+			&fn.EndedAt,
 			&fn.Duration,
+			&totalCount,
 		)
+		if err != nil {
+			return nil, 0, err
+		}
 		finisedWorkouts = append(finisedWorkouts, fn)
 	}
 
-	return finisedWorkouts, nil
+	return finisedWorkouts, totalCount, rows.Err()
+}
+
+// GetByID returns the finished workout together with its sets.
+func (s FinishedWorkoutsStore) GetByID(ctx context.Context, id int64) (*FinishedWorkout, error) {
+	query := `
+		SELECT fw.id, fw.user_id, fw.workout_id, w.name, fw.started_at, fw.ended_at, fw.duration
+		FROM finished_workouts fw
+		JOIN workouts w ON w.id = fw.workout_id
+		WHERE fw.id = $1
+	`
+	fn := &FinishedWorkout{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&fn.ID,
+		&fn.UserID,
+		&fn.WorkoutID,
+		&fn.WorkoutName,
+		&fn.StartedAt,
+		&fn.EndedAt,
+		&fn.Duration,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	sets, err := s.getSets(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fn.Sets = sets
+
+	return fn, nil
+}
+
+func (s FinishedWorkoutsStore) getSets(ctx context.Context, finishedWorkoutID int64) ([]WorkoutSet, error) {
+	query := `
+		SELECT id, finished_workout_id, exercise_id, set_number, reps, weight, duration, rpe
+		FROM finished_workout_sets
+		WHERE finished_workout_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query, finishedWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]WorkoutSet, 0)
+	for rows.Next() {
+		var ws WorkoutSet
+		err := rows.Scan(
+			&ws.ID,
+			&ws.FinishedWorkoutID,
+			&ws.ExerciseID,
+			&ws.SetNumber,
+			&ws.Reps,
+			&ws.Weight,
+			&ws.Duration,
+			&ws.RPE,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, ws)
+	}
+
+	return sets, rows.Err()
+}
+
+// create saves the finished workout with its sets and updates the personal
+// records it beats.
+func (s FinishedWorkoutsStore) create(ctx context.Context, tx *sql.Tx, fn *FinishedWorkout) error {
+	query := `
+		INSERT INTO finished_workouts (user_id, workout_id, started_at, ended_at, duration)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err := tx.QueryRowContext(ctx, query, fn.UserID, fn.WorkoutID, fn.StartedAt, fn.EndedAt, fn.Duration).
+		Scan(&fn.ID)
+	if err != nil {
+		return fkViolationAsNotFound(err)
+	}
+
+	for i := range fn.Sets {
+		fn.Sets[i].ID = 0
+		fn.Sets[i].FinishedWorkoutID = fn.ID
+		if err := s.addSet(ctx, tx, &fn.Sets[i]); err != nil {
+			return err
+		}
+	}
+
+	records, err := updateRecords(ctx, tx, fn)
+	if err != nil {
+		return err
+	}
+	fn.NewRecords = records
+
+	return nil
+}
+
+func (s FinishedWorkoutsStore) addSet(ctx context.Context, tx *sql.Tx, ws *WorkoutSet) error {
+	query := `
+		INSERT INTO finished_workout_sets (finished_workout_id, exercise_id, set_number, reps, weight, duration, rpe)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		ws.FinishedWorkoutID,
+		ws.ExerciseID,
+		ws.SetNumber,
+		ws.Reps,
+		ws.Weight,
+		ws.Duration,
+		ws.RPE,
+	).Scan(&ws.ID)
+	if err != nil {
+		return fkViolationAsNotFound(err)
+	}
+	return nil
+}
+
+// fkViolationAsNotFound maps foreign key violations, which happen when a
+// referenced workout or exercise does not exist, to ErrNotFound.
+func fkViolationAsNotFound(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrNotFound
+	}
+	return err
 }
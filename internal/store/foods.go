@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+)
+
+// Food holds the macronutrients of 100 grams of the food, calories in kcal
+// and the rest in grams.
+type Food struct {
+	ID            int64   `json:"id"`
+	UserID        *int64  `json:"user_id"`
+	Barcode       *string `json:"barcode"       validate:"omitnil,max=64"`
+	Name          string  `json:"name"          validate:"required,max=255"`
+	Calories      float32 `json:"calories"      validate:"gte=0"`
+	Proteins      float32 `json:"proteins"      validate:"gte=0,lte=100"`
+	Fats          float32 `json:"fats"          validate:"gte=0,lte=100"`
+	Carbohydrates float32 `json:"carbohydrates" validate:"gte=0,lte=100"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+type FoodsStore struct {
+	db *sql.DB
+}
+
+const foodColumns = `id, user_id, barcode, name, calories, proteins, fats, carbohydrates, created_at`
+
+func (s *FoodsStore) Create(ctx context.Context, f *Food) error {
+	query := `
+		INSERT INTO foods (user_id, barcode, name, calories, proteins, fats, carbohydrates)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		f.UserID,
+		f.Barcode,
+		f.Name,
+		f.Calories,
+		f.Proteins,
+		f.Fats,
+		f.Carbohydrates,
+	).Scan(&f.ID, &f.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Upsert inserts the foods in one transaction, foods with a known barcode
+// are updated instead.
+func (s *FoodsStore) Upsert(ctx context.Context, foods []Food) error {
+	query := `
+		INSERT INTO foods (barcode, name, calories, proteins, fats, carbohydrates)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (barcode) DO UPDATE
+		SET name = EXCLUDED.name, calories = EXCLUDED.calories, proteins = EXCLUDED.proteins,
+		  fats = EXCLUDED.fats, carbohydrates = EXCLUDED.carbohydrates
+	`
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, f := range foods {
+			_, err := stmt.ExecContext(ctx, f.Barcode, f.Name, f.Calories, f.Proteins, f.Fats, f.Carbohydrates)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *FoodsStore) GetByID(ctx context.Context, id int64) (*Food, error) {
+	query := `SELECT ` + foodColumns + ` FROM foods WHERE id = $1`
+
+	var f Food
+	if err := scanFood(s.db.QueryRowContext(ctx, query, id), &f); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (s *FoodsStore) GetByBarcode(ctx context.Context, barcode string) (*Food, error) {
+	query := `SELECT ` + foodColumns + ` FROM foods WHERE barcode = $1`
+
+	var f Food
+	if err := scanFood(s.db.QueryRowContext(ctx, query, barcode), &f); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (s *FoodsStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]Food, error) {
+	query := `
+		SELECT ` + foodColumns + `
+		FROM foods
+		WHERE name ILIKE '%' || $1 || '%'
+		ORDER BY name ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	return s.query(ctx, query, fq.Search, fq.Limit, fq.Offset)
+}
+
+// Search returns the foods whose name is similar to the search, best
+// matches first. It relies on the trigram index of the foods name and so
+// tolerates typos.
+func (s *FoodsStore) Search(ctx context.Context, fq pagination.PaginatedQuery) ([]Food, error) {
+	query := `
+		SELECT ` + foodColumns + `
+		FROM foods
+		WHERE name % $1 OR name ILIKE '%' || $1 || '%'
+		ORDER BY similarity(name, $1) DESC, name
+		LIMIT $2 OFFSET $3
+	`
+	return s.query(ctx, query, fq.Search, fq.Limit, fq.Offset)
+}
+
+func (s *FoodsStore) query(ctx context.Context, query string, args ...any) ([]Food, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foods := make([]Food, 0)
+	for rows.Next() {
+		var f Food
+		if err := scanFood(rows, &f); err != nil {
+			return nil, err
+		}
+		foods = append(foods, f)
+	}
+
+	return foods, rows.Err()
+}
+
+func scanFood(row interface{ Scan(...any) error }, f *Food) error {
+	return row.Scan(
+		&f.ID,
+		&f.UserID,
+		&f.Barcode,
+		&f.Name,
+		&f.Calories,
+		&f.Proteins,
+		&f.Fats,
+		&f.Carbohydrates,
+		&f.CreatedAt,
+	)
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+// Meal is a food eaten by a user, the macronutrients are computed for the
+// eaten grams from the food.
+type Meal struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	FoodID        int64     `json:"food_id"`
+	FoodName      string    `json:"food_name"`
+	Meal          string    `json:"meal"`
+	Grams         float32   `json:"grams"`
+	Date          time.Time `json:"date"`
+	Calories      float32   `json:"calories"`
+	Proteins      float32   `json:"proteins"`
+	Fats          float32   `json:"fats"`
+	Carbohydrates float32   `json:"carbohydrates"`
+}
+
+// DailyIntake is the calories a user logged on a day.
+type DailyIntake struct {
+	Date     time.Time `json:"date"`
+	Calories float32   `json:"calories"`
+}
+
+type MealsStore struct {
+	db *sql.DB
+}
+
+func (s *MealsStore) Create(ctx context.Context, m *Meal) error {
+	query := `
+		WITH inserted AS (
+		  INSERT INTO meals (user_id, food_id, meal, grams, date)
+		  VALUES ($1, $2, $3, $4, $5)
+		  RETURNING id, food_id, grams
+		)
+		SELECT i.id, f.name, f.calories * i.grams / 100, f.proteins * i.grams / 100,
+		  f.fats * i.grams / 100, f.carbohydrates * i.grams / 100
+		FROM inserted i
+		JOIN foods f ON f.id = i.food_id
+	`
+	err := s.db.QueryRowContext(ctx, query, m.UserID, m.FoodID, m.Meal, m.Grams, m.Date).Scan(
+		&m.ID,
+		&m.FoodName,
+		&m.Calories,
+		&m.Proteins,
+		&m.Fats,
+		&m.Carbohydrates,
+	)
+	if err != nil {
+		return fkViolationAsNotFound(err)
+	}
+
+	return nil
+}
+
+// GetByDate returns the meals the user ate on the date, in meal order.
+func (s *MealsStore) GetByDate(ctx context.Context, userID int64, date time.Time) ([]Meal, error) {
+	query := `
+		SELECT m.id, m.user_id, m.food_id, f.name, m.meal, m.grams, m.date,
+		  f.calories * m.grams / 100, f.proteins * m.grams / 100,
+		  f.fats * m.grams / 100, f.carbohydrates * m.grams / 100
+		FROM meals m
+		JOIN foods f ON f.id = m.food_id
+		WHERE m.user_id = $1 AND m.date = $2::date
+		ORDER BY array_position(ARRAY['breakfast', 'lunch', 'dinner', 'snack'], m.meal::text), m.created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	meals := make([]Meal, 0)
+	for rows.Next() {
+		var m Meal
+		err := rows.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.FoodID,
+			&m.FoodName,
+			&m.Meal,
+			&m.Grams,
+			&m.Date,
+			&m.Calories,
+			&m.Proteins,
+			&m.Fats,
+			&m.Carbohydrates,
+		)
+		if err != nil {
+			return nil, err
+		}
+		meals = append(meals, m)
+	}
+
+	return meals, rows.Err()
+}
+
+// GetDailyCalories returns the calories eaten per day from since until the
+// day before until, days without logged meals are left out.
+func (s *MealsStore) GetDailyCalories(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]DailyIntake, error) {
+	query := `
+		SELECT m.date, SUM(f.calories * m.grams / 100)
+		FROM meals m
+		JOIN foods f ON f.id = m.food_id
+		WHERE m.user_id = $1 AND m.date >= $2::date AND m.date < $3::date
+		GROUP BY m.date
+		ORDER BY m.date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intake := make([]DailyIntake, 0)
+	for rows.Next() {
+		var di DailyIntake
+		if err := rows.Scan(&di.Date, &di.Calories); err != nil {
+			return nil, err
+		}
+		intake = append(intake, di)
+	}
+
+	return intake, rows.Err()
+}
+
+func (s *MealsStore) Delete(ctx context.Context, id, userID int64) error {
+	return execAffectingOne(ctx, s.db, `DELETE FROM meals WHERE id = $1 AND user_id = $2`, id, userID)
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	MetricBodyFat          = "body_fat"
+	MetricWaist            = "waist"
+	MetricChest            = "chest"
+	MetricHips             = "hips"
+	MetricArms             = "arms"
+	MetricThighs           = "thighs"
+	MetricRestingHeartRate = "resting_heart_rate"
+)
+
+// MetricUnits are the units the metrics are stored in.
+var MetricUnits = map[string]string{
+	MetricBodyFat:          "%",
+	MetricWaist:            "cm",
+	MetricChest:            "cm",
+	MetricHips:             "cm",
+	MetricArms:             "cm",
+	MetricThighs:           "cm",
+	MetricRestingHeartRate: "bpm",
+}
+
+// Measurement is the value of a body metric on a day, a user has at most one
+// per metric and day.
+type Measurement struct {
+	UserID int64     `json:"user_id"`
+	Metric string    `json:"metric"`
+	Date   time.Time `json:"date"`
+	Value  float32   `json:"value"`
+	Unit   string    `json:"unit"`
+}
+
+type MeasurementsStore struct {
+	db *sql.DB
+}
+
+func (s *MeasurementsStore) Add(ctx context.Context, m *Measurement) error {
+	query := `
+		INSERT INTO measurements (user_id, metric, date, value, unit) VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := s.db.ExecContext(ctx, query, m.UserID, m.Metric, m.Date, m.Value, m.Unit)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *MeasurementsStore) Update(ctx context.Context, m *Measurement) error {
+	query := `
+		UPDATE measurements SET value = $1, unit = $2
+		WHERE user_id = $3 AND metric = $4 AND date = $5::date
+	`
+	return execAffectingOne(ctx, s.db, query, m.Value, m.Unit, m.UserID, m.Metric, m.Date)
+}
+
+// GetRange returns the measurements between since and until, oldest first.
+// An empty metric returns all metrics.
+func (s *MeasurementsStore) GetRange(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	since, until time.Time,
+) ([]Measurement, error) {
+	query := `
+		SELECT user_id, metric, date, value, unit FROM measurements
+		WHERE user_id = $1 AND ($2 = '' OR metric = $2) AND date >= $3::date AND date <= $4::date
+		ORDER BY date, metric
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, metric, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMeasurements(rows)
+}
+
+// GetLatest returns the last measurement of every metric the user logged.
+func (s *MeasurementsStore) GetLatest(ctx context.Context, userID int64) ([]Measurement, error) {
+	query := `
+		SELECT DISTINCT ON (metric) user_id, metric, date, value, unit FROM measurements
+		WHERE user_id = $1
+		ORDER BY metric, date DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMeasurements(rows)
+}
+
+// GetLatestAt returns the last measurement of the metric logged on or
+// before the date.
+func (s *MeasurementsStore) GetLatestAt(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	date time.Time,
+) (*Measurement, error) {
+	query := `
+		SELECT user_id, metric, date, value, unit FROM measurements
+		WHERE user_id = $1 AND metric = $2 AND date <= $3::date
+		ORDER BY date DESC
+		LIMIT 1
+	`
+	m := &Measurement{}
+	err := s.db.QueryRowContext(ctx, query, userID, metric, date).
+		Scan(&m.UserID, &m.Metric, &m.Date, &m.Value, &m.Unit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func scanMeasurements(rows *sql.Rows) ([]Measurement, error) {
+	defer rows.Close()
+
+	measurements := make([]Measurement, 0)
+	for rows.Next() {
+		var m Measurement
+		if err := rows.Scan(&m.UserID, &m.Metric, &m.Date, &m.Value, &m.Unit); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m)
+	}
+
+	return measurements, rows.Err()
+}
@@ -0,0 +1,193 @@
+package memstore
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type ActiveWorkoutsStore struct {
+	db *db
+}
+
+// Start begins a new session. It returns ErrConflict when the user already has
+// one and ErrNotFound when the workout does not exist.
+func (s *ActiveWorkoutsStore) Start(ctx context.Context, aw *store.ActiveWorkout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, err := s.byUserID(aw.UserID); err == nil {
+		return store.ErrConflict
+	}
+	if _, ok := s.db.users[aw.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.db.workouts[aw.WorkoutID]; !ok {
+		return store.ErrNotFound
+	}
+
+	aw.ID = s.db.nextID("active_workouts")
+	aw.StartedAt = now()
+	aw.UpdatedAt = aw.StartedAt
+	aw.Sets = make([]store.WorkoutSet, 0)
+	s.db.activeWorkouts[aw.ID] = &store.ActiveWorkout{
+		ID:        aw.ID,
+		UserID:    aw.UserID,
+		WorkoutID: aw.WorkoutID,
+		StartedAt: aw.StartedAt,
+		UpdatedAt: aw.UpdatedAt,
+		Sets:      make([]store.WorkoutSet, 0),
+	}
+
+	return nil
+}
+
+// GetByUserID returns the session of the user together with its sets.
+func (s *ActiveWorkoutsStore) GetByUserID(ctx context.Context, userID int64) (*store.ActiveWorkout, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	aw, err := s.byUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := *aw
+	if aw.PausedAt != nil {
+		pausedAt := *aw.PausedAt
+		res.PausedAt = &pausedAt
+	}
+	res.Sets = append(make([]store.WorkoutSet, 0, len(aw.Sets)), aw.Sets...)
+	return &res, nil
+}
+
+// Pause returns ErrConflict when the session is already paused.
+func (s *ActiveWorkoutsStore) Pause(ctx context.Context, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	aw, ok := s.db.activeWorkouts[id]
+	if !ok || aw.IsPaused() {
+		return store.ErrConflict
+	}
+
+	pausedAt := now()
+	aw.PausedAt = &pausedAt
+	aw.UpdatedAt = pausedAt
+
+	return nil
+}
+
+// Resume returns ErrConflict when the session is not paused.
+func (s *ActiveWorkoutsStore) Resume(ctx context.Context, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	aw, ok := s.db.activeWorkouts[id]
+	if !ok || !aw.IsPaused() {
+		return store.ErrConflict
+	}
+
+	resumedAt := now()
+	aw.PausedDuration += int(math.Round(resumedAt.Sub(*aw.PausedAt).Seconds()))
+	aw.PausedAt = nil
+	aw.UpdatedAt = resumedAt
+
+	return nil
+}
+
+// AddSet appends the set to the session, numbering it after the previous sets
+// of the same exercise.
+func (s *ActiveWorkoutsStore) AddSet(ctx context.Context, activeWorkoutID int64, ws *store.WorkoutSet) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	aw, ok := s.db.activeWorkouts[activeWorkoutID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.db.exercises[ws.ExerciseID]; !ok {
+		return store.ErrNotFound
+	}
+
+	ws.SetNumber = 1
+	for _, prev := range aw.Sets {
+		if prev.ExerciseID == ws.ExerciseID {
+			ws.SetNumber = max(ws.SetNumber, prev.SetNumber+1)
+		}
+	}
+	ws.ID = s.db.nextID("active_workout_sets")
+	aw.Sets = append(aw.Sets, *ws)
+	aw.UpdatedAt = now()
+
+	return nil
+}
+
+// Finish removes the session and saves it as a finished workout ending at
+// endedAt, it returns store.ErrNotFound when the session was already removed.
+func (s *ActiveWorkoutsStore) Finish(
+	ctx context.Context,
+	aw *store.ActiveWorkout,
+	endedAt time.Time,
+) (*store.FinishedWorkout, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	fn := &store.FinishedWorkout{
+		UserID:    aw.UserID,
+		WorkoutID: aw.WorkoutID,
+		StartedAt: aw.StartedAt,
+		EndedAt:   endedAt,
+		Duration:  int(aw.Duration(endedAt).Seconds()),
+		Sets:      aw.Sets,
+	}
+	if _, ok := s.db.activeWorkouts[aw.ID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	if err := s.db.createFinishedWorkout(fn); err != nil {
+		return nil, err
+	}
+	delete(s.db.activeWorkouts, aw.ID)
+
+	return fn, nil
+}
+
+func (s *ActiveWorkoutsStore) Delete(ctx context.Context, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.activeWorkouts[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.activeWorkouts, id)
+
+	return nil
+}
+
+// DeleteStale removes sessions which were not updated since before and
+// returns how many were removed.
+func (s *ActiveWorkoutsStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var deleted int64
+	for id, aw := range s.db.activeWorkouts {
+		if aw.UpdatedAt.Before(before) {
+			delete(s.db.activeWorkouts, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *ActiveWorkoutsStore) byUserID(userID int64) (*store.ActiveWorkout, error) {
+	for _, aw := range s.db.activeWorkouts {
+		if aw.UserID == userID {
+			return aw, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
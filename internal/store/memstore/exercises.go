@@ -0,0 +1,254 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type ExerciseStore struct {
+	db *db
+}
+
+func (s *ExerciseStore) GetAll(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+) ([]store.Exercise, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.list(fq, func(e *store.Exercise) bool {
+		return (contains(e.Name, fq.Search) || contains(e.Description, fq.Search)) &&
+			containsAll(e.Muscles, fq.Tags)
+	}), nil
+}
+
+func (s *ExerciseStore) Create(ctx context.Context, e *store.Exercise) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[e.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	e.ID = s.db.nextID("exercises")
+	e.CreatedAt = timestamp()
+	s.db.exercises[e.ID] = &store.Exercise{
+		ID:           e.ID,
+		UserID:       e.UserID,
+		Name:         e.Name,
+		Description:  e.Description,
+		IsDuration:   e.IsDuration,
+		Duration:     e.Duration,
+		TutorialLink: e.TutorialLink,
+		CreatedAt:    e.CreatedAt,
+		Muscles:      slices.Clone(e.Muscles),
+	}
+
+	return nil
+}
+
+func (s *ExerciseStore) GetByID(ctx context.Context, id int64) (*store.Exercise, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	e, ok := s.db.exercises[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := copyExercise(e)
+	res.IsHidden = e.IsHidden
+	return &res, nil
+}
+
+func (s *ExerciseStore) GetUsersExercises(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Exercise, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.list(fq, func(e *store.Exercise) bool {
+		return e.UserID == userID
+	}), nil
+}
+
+func (s *ExerciseStore) Update(ctx context.Context, e *store.Exercise) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	saved, ok := s.db.exercises[e.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	saved.Name = e.Name
+	saved.Description = e.Description
+	saved.IsDuration = e.IsDuration
+	saved.Duration = e.Duration
+	saved.TutorialLink = e.TutorialLink
+	saved.Muscles = slices.Clone(e.Muscles)
+
+	return nil
+}
+
+// Delete removes the exercise and its likes. It returns ErrConflict when the
+// exercise is still part of a workout or of logged sets.
+func (s *ExerciseStore) Delete(ctx context.Context, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.exercises[id]; !ok {
+		return store.ErrNotFound
+	}
+	if s.db.exerciseInUse(id) {
+		return store.ErrConflict
+	}
+
+	for l := range s.db.exerciseLikes {
+		if l.id == id {
+			delete(s.db.exerciseLikes, l)
+		}
+	}
+	for k := range s.db.records {
+		if k.exerciseID == id {
+			delete(s.db.records, k)
+		}
+	}
+	delete(s.db.exercises, id)
+
+	return nil
+}
+
+// GetDependentWorkouts returns the workouts which include the exercise or
+// which have logged sets of it, finished or in progress.
+func (s *ExerciseStore) GetDependentWorkouts(ctx context.Context, id int64) ([]store.Workout, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	dependent := make(map[int64]bool)
+	for workoutID, wes := range s.db.workoutExercises {
+		for _, we := range wes {
+			if we.ExerciseID == id {
+				dependent[workoutID] = true
+			}
+		}
+	}
+	for _, fw := range s.db.finishedWorkouts {
+		for _, ws := range fw.Sets {
+			if ws.ExerciseID == id {
+				dependent[fw.WorkoutID] = true
+			}
+		}
+	}
+	for _, aw := range s.db.activeWorkouts {
+		for _, ws := range aw.Sets {
+			if ws.ExerciseID == id {
+				dependent[aw.WorkoutID] = true
+			}
+		}
+	}
+
+	workouts := make([]store.Workout, 0)
+	for workoutID := range dependent {
+		w, ok := s.db.workouts[workoutID]
+		if !ok {
+			continue
+		}
+		workouts = append(workouts, store.Workout{
+			ID:           w.ID,
+			UserID:       w.UserID,
+			Name:         w.Name,
+			Description:  w.Description,
+			TutorialLink: w.TutorialLink,
+			CreatedAt:    w.CreatedAt,
+		})
+	}
+	sortBy(workouts, "asc", func(a, b store.Workout) bool { return a.ID < b.ID })
+
+	return workouts, nil
+}
+
+func (s *ExerciseStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	e, ok := s.db.exercises[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	e.IsHidden = hidden
+
+	return nil
+}
+
+// list returns the visible exercises matching the filter with their likes,
+// ordered by likes. Like the Postgres store it returns nil when nothing
+// matches.
+func (s *ExerciseStore) list(fq pagination.PaginatedQuery, match func(*store.Exercise) bool) []store.Exercise {
+	var exercises []store.Exercise
+	for _, e := range s.db.exercises {
+		if e.IsHidden || !match(e) {
+			continue
+		}
+
+		res := copyExercise(e)
+		for l := range s.db.exerciseLikes {
+			if l.id == e.ID {
+				res.Likes++
+			}
+		}
+		exercises = append(exercises, res)
+	}
+	sortBy(exercises, "asc", func(a, b store.Exercise) bool { return a.ID < b.ID })
+	sortBy(exercises, fq.Sort, func(a, b store.Exercise) bool { return a.Likes < b.Likes })
+
+	return page(exercises, fq.Limit, fq.Offset)
+}
+
+// exerciseInUse reports whether a workout or logged sets reference the
+// exercise, which makes Postgres refuse to delete it.
+func (d *db) exerciseInUse(id int64) bool {
+	for _, wes := range d.workoutExercises {
+		for _, we := range wes {
+			if we.ExerciseID == id {
+				return true
+			}
+		}
+	}
+	for _, fw := range d.finishedWorkouts {
+		for _, ws := range fw.Sets {
+			if ws.ExerciseID == id {
+				return true
+			}
+		}
+	}
+	for _, aw := range d.activeWorkouts {
+		for _, ws := range aw.Sets {
+			if ws.ExerciseID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// copyExercise copies the columns of the exercise, without the likes and the
+// hidden flag which are only read by some queries.
+func copyExercise(e *store.Exercise) store.Exercise {
+	return store.Exercise{
+		ID:           e.ID,
+		UserID:       e.UserID,
+		Name:         e.Name,
+		Description:  e.Description,
+		IsDuration:   e.IsDuration,
+		Duration:     e.Duration,
+		TutorialLink: e.TutorialLink,
+		CreatedAt:    e.CreatedAt,
+		Muscles:      slices.Clone(e.Muscles),
+	}
+}
@@ -0,0 +1,116 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type FinishedWorkoutsStore struct {
+	db *db
+}
+
+func (s *FinishedWorkoutsStore) Create(ctx context.Context, fn *store.FinishedWorkout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	return s.db.createFinishedWorkout(fn)
+}
+
+func (s *FinishedWorkoutsStore) GetAll(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.FinishedWorkout, int, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	finishedWorkouts := make([]store.FinishedWorkout, 0)
+	for _, fw := range s.db.finishedWorkouts {
+		w, ok := s.db.workouts[fw.WorkoutID]
+		if fw.UserID != userID || !ok {
+			continue
+		}
+
+		res := *fw
+		res.WorkoutName = w.Name
+		res.Sets = nil
+		finishedWorkouts = append(finishedWorkouts, res)
+	}
+	sortBy(finishedWorkouts, fq.Sort, func(a, b store.FinishedWorkout) bool {
+		return a.EndedAt.Before(b.EndedAt)
+	})
+	total := len(finishedWorkouts)
+
+	return page(finishedWorkouts, fq.Limit, fq.Offset), total, nil
+}
+
+// GetByID returns the finished workout together with its sets.
+func (s *FinishedWorkoutsStore) GetByID(ctx context.Context, id int64) (*store.FinishedWorkout, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	fw, ok := s.db.finishedWorkouts[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	w, ok := s.db.workouts[fw.WorkoutID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := *fw
+	res.WorkoutName = w.Name
+	res.Sets = append(make([]store.WorkoutSet, 0, len(fw.Sets)), fw.Sets...)
+	return &res, nil
+}
+
+// createFinishedWorkout saves the finished workout with its sets and updates
+// the personal records it beats.
+func (d *db) createFinishedWorkout(fn *store.FinishedWorkout) error {
+	if _, ok := d.users[fn.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := d.workouts[fn.WorkoutID]; !ok {
+		return store.ErrNotFound
+	}
+	for _, ws := range fn.Sets {
+		if _, ok := d.exercises[ws.ExerciseID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+
+	fn.ID = d.nextID("finished_workouts")
+	for i := range fn.Sets {
+		fn.Sets[i].ID = d.nextID("finished_workout_sets")
+		fn.Sets[i].FinishedWorkoutID = fn.ID
+	}
+
+	d.finishedWorkouts[fn.ID] = &store.FinishedWorkout{
+		ID:        fn.ID,
+		UserID:    fn.UserID,
+		WorkoutID: fn.WorkoutID,
+		StartedAt: fn.StartedAt.Round(time.Second),
+		EndedAt:   fn.EndedAt.Round(time.Second),
+		Duration:  fn.Duration,
+		Sets:      slices.Clone(fn.Sets),
+	}
+	fn.NewRecords = d.updateRecords(fn)
+
+	return nil
+}
+
+// deleteFinishedWorkout removes the finished workout with its sets, the
+// records it holds are kept without the finished workout.
+func (d *db) deleteFinishedWorkout(id int64) {
+	for k, pr := range d.records {
+		if pr.FinishedWorkoutID != nil && *pr.FinishedWorkoutID == id {
+			pr.FinishedWorkoutID = nil
+			d.records[k] = pr
+		}
+	}
+	delete(d.finishedWorkouts, id)
+}
@@ -0,0 +1,151 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/trigram"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type FoodsStore struct {
+	db *db
+}
+
+func (s *FoodsStore) Create(ctx context.Context, f *store.Food) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if f.Barcode != nil {
+		if _, ok := s.byBarcode(*f.Barcode); ok {
+			return store.ErrConflict
+		}
+	}
+
+	s.insert(f)
+
+	return nil
+}
+
+// Upsert inserts the foods in one transaction, foods with a known barcode
+// are updated instead.
+func (s *FoodsStore) Upsert(ctx context.Context, foods []store.Food) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, f := range foods {
+		if f.Barcode != nil {
+			if saved, ok := s.byBarcode(*f.Barcode); ok {
+				saved.Name = f.Name
+				saved.Calories = f.Calories
+				saved.Proteins = f.Proteins
+				saved.Fats = f.Fats
+				saved.Carbohydrates = f.Carbohydrates
+				continue
+			}
+		}
+
+		f.UserID = nil
+		s.insert(&f)
+	}
+
+	return nil
+}
+
+func (s *FoodsStore) GetByID(ctx context.Context, id int64) (*store.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	f, ok := s.db.foods[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := copyFood(f)
+	return &res, nil
+}
+
+func (s *FoodsStore) GetByBarcode(ctx context.Context, barcode string) (*store.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	f, ok := s.byBarcode(barcode)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := copyFood(f)
+	return &res, nil
+}
+
+func (s *FoodsStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]store.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	foods := s.list(func(f *store.Food) bool { return contains(f.Name, fq.Search) })
+	sortBy(foods, fq.Sort, func(a, b store.Food) bool { return a.Name < b.Name })
+
+	return page(foods, fq.Limit, fq.Offset), nil
+}
+
+// Search returns the foods whose name is similar to the search, best
+// matches first. Similarity is computed like the pg_trgm extension, so it
+// tolerates typos.
+func (s *FoodsStore) Search(ctx context.Context, fq pagination.PaginatedQuery) ([]store.Food, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	foods := s.list(func(f *store.Food) bool {
+		return trigram.Similar(f.Name, fq.Search) || contains(f.Name, fq.Search)
+	})
+	sortBy(foods, "asc", func(a, b store.Food) bool {
+		sa, sb := trigram.Similarity(a.Name, fq.Search), trigram.Similarity(b.Name, fq.Search)
+		if sa != sb {
+			return sa > sb
+		}
+		return a.Name < b.Name
+	})
+
+	return page(foods, fq.Limit, fq.Offset), nil
+}
+
+func (s *FoodsStore) insert(f *store.Food) {
+	f.ID = s.db.nextID("foods")
+	f.CreatedAt = timestamp()
+	saved := copyFood(f)
+	s.db.foods[f.ID] = &saved
+}
+
+func (s *FoodsStore) byBarcode(barcode string) (*store.Food, bool) {
+	for _, f := range s.db.foods {
+		if f.Barcode != nil && *f.Barcode == barcode {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func (s *FoodsStore) list(match func(*store.Food) bool) []store.Food {
+	foods := make([]store.Food, 0)
+	for _, f := range s.db.foods {
+		if match(f) {
+			foods = append(foods, copyFood(f))
+		}
+	}
+	sortBy(foods, "asc", func(a, b store.Food) bool { return a.ID < b.ID })
+	return foods
+}
+
+// copyFood copies the food without sharing its pointer fields.
+func copyFood(f *store.Food) store.Food {
+	res := *f
+	if f.UserID != nil {
+		userID := *f.UserID
+		res.UserID = &userID
+	}
+	if f.Barcode != nil {
+		barcode := *f.Barcode
+		res.Barcode = &barcode
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type LikesStore struct {
+	db *db
+}
+
+func (s *LikesStore) CreateExercise(ctx context.Context, userID, exerciseID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.exercises[exerciseID]; !ok {
+		return store.ErrNotFound
+	}
+	return s.db.like(s.db.exerciseLikes, userID, exerciseID)
+}
+
+func (s *LikesStore) DeleteExercise(ctx context.Context, userID, exerciseID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.exerciseLikes, pair{userID, exerciseID})
+	return nil
+}
+
+func (s *LikesStore) CreateWorkout(ctx context.Context, userID, workoutID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[workoutID]; !ok {
+		return store.ErrNotFound
+	}
+	return s.db.like(s.db.workoutLikes, userID, workoutID)
+}
+
+func (s *LikesStore) DeleteWorkout(ctx context.Context, userID, workoutID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	delete(s.db.workoutLikes, pair{userID, workoutID})
+	return nil
+}
+
+func (d *db) like(likes map[pair]struct{}, userID, id int64) error {
+	if _, ok := d.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := likes[pair{userID, id}]; ok {
+		return store.ErrConflict
+	}
+
+	likes[pair{userID, id}] = struct{}{}
+	return nil
+}
@@ -0,0 +1,130 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// mealOrder is the order the meals of a day are returned in.
+var mealOrder = []string{store.MealBreakfast, store.MealLunch, store.MealDinner, store.MealSnack}
+
+type meal struct {
+	store.Meal
+	// seq keeps the insertion order, the meals of the same kind are returned
+	// in it
+	seq int64
+}
+
+type MealsStore struct {
+	db *db
+}
+
+func (s *MealsStore) Create(ctx context.Context, m *store.Meal) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[m.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	f, ok := s.db.foods[m.FoodID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	m.ID = s.db.nextID("meals")
+	m.FoodName = f.Name
+	withMacros(m, f)
+
+	saved := *m
+	saved.Date = day(m.Date)
+	s.db.meals[m.ID] = &meal{Meal: saved, seq: m.ID}
+
+	return nil
+}
+
+// GetByDate returns the meals the user ate on the date, in meal order.
+func (s *MealsStore) GetByDate(ctx context.Context, userID int64, date time.Time) ([]store.Meal, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	date = day(date)
+	var found []*meal
+	for _, m := range s.db.meals {
+		if m.UserID == userID && m.Date.Equal(date) {
+			found = append(found, m)
+		}
+	}
+	sortBy(found, "asc", func(a, b *meal) bool {
+		if a.Meal.Meal != b.Meal.Meal {
+			return slices.Index(mealOrder, a.Meal.Meal) < slices.Index(mealOrder, b.Meal.Meal)
+		}
+		return a.seq < b.seq
+	})
+
+	meals := make([]store.Meal, 0, len(found))
+	for _, m := range found {
+		f, ok := s.db.foods[m.FoodID]
+		if !ok {
+			continue
+		}
+		res := m.Meal
+		res.FoodName = f.Name
+		withMacros(&res, f)
+		meals = append(meals, res)
+	}
+
+	return meals, nil
+}
+
+// GetDailyCalories returns the calories eaten per day from since until the
+// day before until, days without logged meals are left out.
+func (s *MealsStore) GetDailyCalories(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.DailyIntake, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	since, until = day(since), day(until)
+	calories := make(map[time.Time]float32)
+	for _, m := range s.db.meals {
+		f, ok := s.db.foods[m.FoodID]
+		if m.UserID != userID || !ok || m.Date.Before(since) || !m.Date.Before(until) {
+			continue
+		}
+		calories[m.Date] += f.Calories * m.Grams / 100
+	}
+
+	intake := make([]store.DailyIntake, 0, len(calories))
+	for d, c := range calories {
+		intake = append(intake, store.DailyIntake{Date: d, Calories: c})
+	}
+	sortBy(intake, "asc", func(a, b store.DailyIntake) bool { return a.Date.Before(b.Date) })
+
+	return intake, nil
+}
+
+func (s *MealsStore) Delete(ctx context.Context, id, userID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	m, ok := s.db.meals[id]
+	if !ok || m.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.db.meals, id)
+
+	return nil
+}
+
+// withMacros computes the macronutrients of the eaten grams of the food.
+func withMacros(m *store.Meal, f *store.Food) {
+	m.Calories = f.Calories * m.Grams / 100
+	m.Proteins = f.Proteins * m.Grams / 100
+	m.Fats = f.Fats * m.Grams / 100
+	m.Carbohydrates = f.Carbohydrates * m.Grams / 100
+}
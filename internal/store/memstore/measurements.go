@@ -0,0 +1,139 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// measurementKey is the primary key of the measurements table, date is
+// truncated with day.
+type measurementKey struct {
+	userID int64
+	metric string
+	date   time.Time
+}
+
+type MeasurementsStore struct {
+	db *db
+}
+
+func (s *MeasurementsStore) Add(ctx context.Context, m *store.Measurement) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := measurementKey{m.UserID, m.Metric, day(m.Date)}
+	if _, ok := s.db.measurements[key]; ok {
+		return store.ErrConflict
+	}
+	if _, ok := s.db.users[m.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	saved := *m
+	saved.Date = key.date
+	s.db.measurements[key] = saved
+
+	return nil
+}
+
+func (s *MeasurementsStore) Update(ctx context.Context, m *store.Measurement) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := measurementKey{m.UserID, m.Metric, day(m.Date)}
+	saved, ok := s.db.measurements[key]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	saved.Value = m.Value
+	saved.Unit = m.Unit
+	s.db.measurements[key] = saved
+
+	return nil
+}
+
+// GetRange returns the measurements between since and until, oldest first.
+// An empty metric returns all metrics.
+func (s *MeasurementsStore) GetRange(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	since, until time.Time,
+) ([]store.Measurement, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	since, until = day(since), day(until)
+	measurements := s.list(userID, func(m store.Measurement) bool {
+		return (metric == "" || m.Metric == metric) && !m.Date.Before(since) && !m.Date.After(until)
+	})
+	sortBy(measurements, "asc", func(a, b store.Measurement) bool {
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.Metric < b.Metric
+	})
+
+	return measurements, nil
+}
+
+// GetLatest returns the last measurement of every metric the user logged.
+func (s *MeasurementsStore) GetLatest(ctx context.Context, userID int64) ([]store.Measurement, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	latest := make(map[string]store.Measurement)
+	for _, m := range s.list(userID, func(store.Measurement) bool { return true }) {
+		if l, ok := latest[m.Metric]; !ok || m.Date.After(l.Date) {
+			latest[m.Metric] = m
+		}
+	}
+
+	measurements := make([]store.Measurement, 0, len(latest))
+	for _, m := range latest {
+		measurements = append(measurements, m)
+	}
+	sortBy(measurements, "asc", func(a, b store.Measurement) bool { return a.Metric < b.Metric })
+
+	return measurements, nil
+}
+
+// GetLatestAt returns the last measurement of the metric logged on or
+// before the date.
+func (s *MeasurementsStore) GetLatestAt(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	date time.Time,
+) (*store.Measurement, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	date = day(date)
+	var latest *store.Measurement
+	for _, m := range s.list(userID, func(m store.Measurement) bool {
+		return m.Metric == metric && !m.Date.After(date)
+	}) {
+		if latest == nil || m.Date.After(latest.Date) {
+			latest = &m
+		}
+	}
+	if latest == nil {
+		return nil, store.ErrNotFound
+	}
+
+	return latest, nil
+}
+
+func (s *MeasurementsStore) list(userID int64, match func(store.Measurement) bool) []store.Measurement {
+	measurements := make([]store.Measurement, 0)
+	for k, m := range s.db.measurements {
+		if k.userID == userID && match(m) {
+			measurements = append(measurements, m)
+		}
+	}
+	return measurements
+}
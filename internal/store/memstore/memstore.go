@@ -0,0 +1,189 @@
+// Package memstore keeps the store.Storage data in memory. It reproduces the
+// behaviour and the errors of the Postgres store, so handlers can be
+// exercised without a database.
+package memstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// db holds the tables of all repositories behind a single lock, which makes
+// every method atomic like a transaction of the Postgres store.
+type db struct {
+	mu     sync.RWMutex
+	lastID map[string]int64
+
+	users          map[int64]*store.User
+	invitations    map[string]token
+	passwordResets map[string]token
+	emailChanges   map[string]emailChange
+	userAttrs      map[int64]*store.UserAttributes
+	weights        map[int64][]weight
+
+	exercises        map[int64]*store.Exercise
+	exerciseLikes    map[pair]struct{}
+	workouts         map[int64]*store.Workout
+	workoutExercises map[int64][]store.WorkoutExercises
+	workoutLikes     map[pair]struct{}
+	reviews          map[pair]*review
+
+	finishedWorkouts map[int64]*store.FinishedWorkout
+	activeWorkouts   map[int64]*store.ActiveWorkout
+	records          map[recordKey]store.PersonalRecord
+	measurements     map[measurementKey]store.Measurement
+
+	foods map[int64]*store.Food
+	meals map[int64]*meal
+
+	sessions map[string]*store.Session
+}
+
+// pair keys the rows identified by a user and another entity.
+type pair struct {
+	userID int64
+	id     int64
+}
+
+type token struct {
+	userID int64
+	exp    time.Time
+}
+
+type weight struct {
+	date   time.Time
+	weight float32
+}
+
+// New returns an empty in-memory storage, all repositories share the same
+// data.
+func New() store.Storage {
+	d := &db{
+		lastID:           make(map[string]int64),
+		users:            make(map[int64]*store.User),
+		invitations:      make(map[string]token),
+		passwordResets:   make(map[string]token),
+		emailChanges:     make(map[string]emailChange),
+		userAttrs:        make(map[int64]*store.UserAttributes),
+		weights:          make(map[int64][]weight),
+		exercises:        make(map[int64]*store.Exercise),
+		exerciseLikes:    make(map[pair]struct{}),
+		workouts:         make(map[int64]*store.Workout),
+		workoutExercises: make(map[int64][]store.WorkoutExercises),
+		workoutLikes:     make(map[pair]struct{}),
+		reviews:          make(map[pair]*review),
+		finishedWorkouts: make(map[int64]*store.FinishedWorkout),
+		activeWorkouts:   make(map[int64]*store.ActiveWorkout),
+		records:          make(map[recordKey]store.PersonalRecord),
+		measurements:     make(map[measurementKey]store.Measurement),
+		foods:            make(map[int64]*store.Food),
+		meals:            make(map[int64]*meal),
+		sessions:         make(map[string]*store.Session),
+	}
+
+	return store.Storage{
+		Users:            &UserStore{d},
+		Exercises:        &ExerciseStore{d},
+		Likes:            &LikesStore{d},
+		Workouts:         &WorkoutStore{d},
+		Reviews:          &ReviewsStore{d},
+		FinishedWorkouts: &FinishedWorkoutsStore{d},
+		ActiveWorkouts:   &ActiveWorkoutsStore{d},
+		PersonalRecords:  &PersonalRecordsStore{d},
+		Measurements:     &MeasurementsStore{d},
+		Foods:            &FoodsStore{d},
+		Meals:            &MealsStore{d},
+		Stats:            &StatsStore{d},
+		Sessions:         &SessionsStore{d},
+	}
+}
+
+// nextID works like a bigserial column of the table.
+func (d *db) nextID(table string) int64 {
+	d.lastID[table]++
+	return d.lastID[table]
+}
+
+// page applies the limit and offset of a query to the sorted items.
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// sortBy sorts the items stably by less, reversed when sort is desc.
+func sortBy[T any](items []T, sortOrder string, less func(a, b T) bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if sortOrder == "desc" {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+// contains matches like ILIKE '%' || sub || '%'.
+func contains(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+// containsAll matches like the array operator @>.
+func containsAll(values, subset []string) bool {
+	for _, s := range subset {
+		found := false
+		for _, v := range values {
+			if v == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// day truncates t to its date, as a DATE column is read back from Postgres.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dateString formats a date like a DATE column scanned into a string.
+func dateString(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// now returns the current time with the precision of a timestamp(0) column.
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+func timestamp() string {
+	return now().Format(time.RFC3339Nano)
+}
+
+func hashToken(plainToken string) string {
+	hash := sha256.Sum256([]byte(plainToken))
+	return hex.EncodeToString(hash[:])
+}
+
+// parseBound parses the since and until strings of a paginated query.
+func parseBound(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(time.DateTime, s, time.Local)
+	return t, err == nil
+}
@@ -0,0 +1,118 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type recordKey struct {
+	userID     int64
+	exerciseID int64
+	kind       string
+}
+
+type PersonalRecordsStore struct {
+	db *db
+}
+
+func (s *PersonalRecordsStore) GetByUserID(ctx context.Context, userID int64) ([]store.PersonalRecord, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	records := make([]store.PersonalRecord, 0)
+	for _, pr := range s.db.records {
+		e, ok := s.db.exercises[pr.ExerciseID]
+		if pr.UserID != userID || !ok {
+			continue
+		}
+		pr.ExerciseName = e.Name
+		records = append(records, pr)
+	}
+	sortBy(records, "asc", func(a, b store.PersonalRecord) bool {
+		if a.ExerciseName != b.ExerciseName {
+			return a.ExerciseName < b.ExerciseName
+		}
+		return a.Kind < b.Kind
+	})
+
+	return records, nil
+}
+
+// GetProgress returns the user's sessions containing the exercise, one point
+// per finished workout, within the since and until bounds of the query.
+func (s *PersonalRecordsStore) GetProgress(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID, exerciseID int64,
+) ([]store.ExerciseProgress, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	since, hasSince := parseBound(fq.Since)
+	until, hasUntil := parseBound(fq.Until)
+
+	progress := make([]store.ExerciseProgress, 0)
+	for _, fw := range s.db.finishedWorkouts {
+		if fw.UserID != userID ||
+			(hasSince && fw.EndedAt.Before(since)) ||
+			(hasUntil && fw.EndedAt.After(until)) {
+			continue
+		}
+
+		p := store.ExerciseProgress{FinishedWorkoutID: fw.ID, Date: fw.EndedAt}
+		for _, ws := range fw.Sets {
+			if ws.ExerciseID != exerciseID {
+				continue
+			}
+			p.Sets++
+			p.MaxWeight = max(p.MaxWeight, ws.Weight)
+			p.Estimated1RM = max(p.Estimated1RM, store.Estimated1RM(ws.Weight, ws.Reps))
+			p.MaxReps = max(p.MaxReps, ws.Reps)
+			p.MaxDuration = max(p.MaxDuration, ws.Duration)
+			p.Volume += ws.Weight * float32(ws.Reps)
+		}
+		if p.Sets > 0 {
+			progress = append(progress, p)
+		}
+	}
+	sortBy(progress, fq.Sort, func(a, b store.ExerciseProgress) bool {
+		return a.Date.Before(b.Date)
+	})
+
+	return page(progress, fq.Limit, fq.Offset), nil
+}
+
+// updateRecords compares the sets of the finished workout with the stored
+// records and saves the ones it beats. It returns the new records.
+func (d *db) updateRecords(fn *store.FinishedWorkout) []store.PersonalRecord {
+	if len(fn.Sets) == 0 {
+		return nil
+	}
+
+	isDuration := make(map[int64]bool)
+	for _, ws := range fn.Sets {
+		isDuration[ws.ExerciseID] = d.exercises[ws.ExerciseID].IsDuration
+	}
+
+	records := make([]store.PersonalRecord, 0)
+	for _, pr := range store.RecordCandidates(fn, isDuration) {
+		key := recordKey{pr.UserID, pr.ExerciseID, pr.Kind}
+		if previous, ok := d.records[key]; ok {
+			if previous.Value >= pr.Value {
+				continue
+			}
+			pr.Previous = &previous.Value
+		}
+
+		saved := pr
+		saved.Previous = nil
+		saved.AchievedAt = pr.AchievedAt.Round(time.Second)
+		d.records[key] = saved
+		records = append(records, pr)
+	}
+
+	return records
+}
@@ -0,0 +1,107 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type review struct {
+	store.WorkoutReview
+	hidden bool
+	// seq keeps the insertion order, which is the order Postgres returns
+	// the reviews in
+	seq int64
+}
+
+type ReviewsStore struct {
+	db *db
+}
+
+func (s *ReviewsStore) CreateWorkout(ctx context.Context, wr *store.WorkoutReview) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := pair{wr.UserID, wr.WorkoutID}
+	if _, ok := s.db.reviews[key]; ok {
+		return store.ErrConflict
+	}
+	if _, ok := s.db.users[wr.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.db.workouts[wr.WorkoutID]; !ok {
+		return store.ErrNotFound
+	}
+
+	wr.CreatedAt = timestamp()
+	s.db.reviews[key] = &review{WorkoutReview: *wr, seq: s.db.nextID("workout_reviews")}
+
+	return nil
+}
+
+func (s *ReviewsStore) Update(ctx context.Context, wr *store.WorkoutReview) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	saved, ok := s.db.reviews[pair{wr.UserID, wr.WorkoutID}]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	saved.Rating = wr.Rating
+	saved.Title = wr.Title
+	saved.Content = wr.Content
+	wr.CreatedAt = saved.CreatedAt
+
+	return nil
+}
+
+func (s *ReviewsStore) Delete(ctx context.Context, userID, workoutID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	key := pair{userID, workoutID}
+	if _, ok := s.db.reviews[key]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.db.reviews, key)
+
+	return nil
+}
+
+func (s *ReviewsStore) Get(ctx context.Context, workoutID int64) ([]store.WorkoutReviewWithMetadata, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var visible []*review
+	for _, r := range s.db.reviews {
+		if r.WorkoutID == workoutID && !r.hidden {
+			visible = append(visible, r)
+		}
+	}
+	sortBy(visible, "asc", func(a, b *review) bool { return a.seq < b.seq })
+
+	var workoutReviews []store.WorkoutReviewWithMetadata
+	for _, r := range visible {
+		wr := store.WorkoutReviewWithMetadata{WorkoutReview: r.WorkoutReview}
+		if u, ok := s.db.users[r.UserID]; ok {
+			wr.Username = u.Username
+		}
+		workoutReviews = append(workoutReviews, wr)
+	}
+
+	return workoutReviews, nil
+}
+
+func (s *ReviewsStore) SetHidden(ctx context.Context, userID, workoutID int64, hidden bool) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	r, ok := s.db.reviews[pair{userID, workoutID}]
+	if !ok {
+		return store.ErrNotFound
+	}
+	r.hidden = hidden
+
+	return nil
+}
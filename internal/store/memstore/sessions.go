@@ -0,0 +1,103 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type SessionsStore struct {
+	db *db
+}
+
+func (s *SessionsStore) Create(ctx context.Context, session *store.Session) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.sessions[session.ID]; ok {
+		return store.ErrConflict
+	}
+	if _, ok := s.db.users[session.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	session.CreatedAt = timestamp()
+	saved := *session
+	s.db.sessions[session.ID] = &saved
+
+	return nil
+}
+
+func (s *SessionsStore) GetByID(ctx context.Context, id string) (*store.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	session, ok := s.db.sessions[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := *session
+	return &res, nil
+}
+
+// Rotate replaces the refresh token of an active session and extends its
+// expiration. The old refresh token can not be used anymore.
+func (s *SessionsStore) Rotate(
+	ctx context.Context,
+	oldToken string,
+	newToken string,
+	exp time.Time,
+) (*store.Session, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	now := time.Now()
+	for _, session := range s.db.sessions {
+		if session.RefreshToken != oldToken || session.RevokedAt.Valid || !session.Exp.After(now) {
+			continue
+		}
+
+		session.RefreshToken = newToken
+		session.Exp = exp
+		return &store.Session{
+			ID:           session.ID,
+			UserID:       session.UserID,
+			RefreshToken: newToken,
+			Exp:          exp,
+			CreatedAt:    session.CreatedAt,
+		}, nil
+	}
+
+	return nil, store.ErrNotFound
+}
+
+func (s *SessionsStore) Revoke(ctx context.Context, id string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if session, ok := s.db.sessions[id]; ok && !session.RevokedAt.Valid {
+		session.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+	}
+
+	return nil
+}
+
+func (s *SessionsStore) RevokeAll(ctx context.Context, userID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.revokeUserSessions(userID)
+
+	return nil
+}
+
+func (d *db) revokeUserSessions(userID int64) {
+	for _, session := range d.sessions {
+		if session.UserID == userID && !session.RevokedAt.Valid {
+			session.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type StatsStore struct {
+	db *db
+}
+
+func (s *StatsStore) Get(ctx context.Context, userID int64, since, until time.Time) (*store.Stats, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	st := &store.Stats{Since: since, Until: until}
+
+	trained := make(map[time.Time]bool)
+	sets := make(map[string]*store.MuscleVolume)
+	var total int
+	for _, fw := range s.db.finishedWorkouts {
+		if fw.UserID != userID || fw.EndedAt.After(until) {
+			continue
+		}
+		trained[day(fw.EndedAt.UTC())] = true
+		if fw.EndedAt.Before(since) {
+			continue
+		}
+
+		st.Workouts++
+		st.TotalDuration += fw.Duration
+		for _, ws := range fw.Sets {
+			e, ok := s.db.exercises[ws.ExerciseID]
+			if !ok {
+				continue
+			}
+			for _, muscle := range e.Muscles {
+				mv, ok := sets[muscle]
+				if !ok {
+					mv = &store.MuscleVolume{Muscle: muscle}
+					sets[muscle] = mv
+				}
+				mv.Sets++
+				mv.Volume += ws.Weight * float32(ws.Reps)
+				mv.Duration += ws.Duration
+				total++
+			}
+		}
+	}
+	if weeks := until.Sub(since).Hours() / (24 * 7); weeks > 0 {
+		st.WorkoutsPerWeek = float64(st.Workouts) / weeks
+	}
+
+	days := make([]time.Time, 0, len(trained))
+	for d := range trained {
+		days = append(days, d)
+	}
+	sortBy(days, "desc", func(a, b time.Time) bool { return a.Before(b) })
+	st.CurrentStreak, st.LongestStreak = store.Streaks(days, since, until)
+
+	st.Muscles = make([]store.MuscleVolume, 0, len(sets))
+	for _, mv := range sets {
+		mv.Share = float32(mv.Sets) / float32(total)
+		st.Muscles = append(st.Muscles, *mv)
+	}
+	sortBy(st.Muscles, "asc", func(a, b store.MuscleVolume) bool {
+		if a.Sets != b.Sets {
+			return a.Sets > b.Sets
+		}
+		return a.Muscle < b.Muscle
+	})
+
+	st.Weight = s.db.weightBetween(userID, day(since), day(until))
+	if n := len(st.Weight); n > 1 {
+		st.WeightChange = st.Weight[n-1].Weight - st.Weight[0].Weight
+	}
+
+	return st, nil
+}
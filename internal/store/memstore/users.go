@@ -0,0 +1,531 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type emailChange struct {
+	token
+	email string
+}
+
+type UserStore struct {
+	db *db
+}
+
+func (s *UserStore) CreateAndInvite(
+	ctx context.Context,
+	user *store.User,
+	token string,
+	exp time.Duration,
+) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if err := s.checkUnique(0, user.Email, user.Username); err != nil {
+		return err
+	}
+
+	user.ID = s.db.nextID("users")
+	user.CreatedAt = timestamp()
+	if user.Units == "" {
+		user.Units = "metric"
+	}
+	s.db.users[user.ID] = &store.User{
+		ID:        user.ID,
+		Email:     user.Email,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+		Role:      store.RoleUser,
+		Units:     user.Units,
+	}
+	s.db.users[user.ID].Password.Hash = user.Password.Hash
+	s.createInvite(user.ID, token, exp)
+
+	user.UserAttr.UserID = user.ID
+	ua := &store.UserAttributes{
+		UserID:        user.ID,
+		IsMale:        user.UserAttr.IsMale,
+		Height:        user.UserAttr.Height,
+		Goal:          user.UserAttr.Goal,
+		WeightGoal:    user.UserAttr.WeightGoal,
+		BirthDate:     user.UserAttr.BirthDate,
+		ActivityLevel: user.UserAttr.ActivityLevel,
+		Formula:       user.UserAttr.Formula,
+	}
+	if ua.ActivityLevel == "" {
+		ua.ActivityLevel = store.ActivityModerate
+	}
+	if ua.Formula == "" {
+		ua.Formula = store.FormulaMifflin
+	}
+	s.db.userAttrs[user.ID] = ua
+	s.db.weights[user.ID] = []weight{{date: day(time.Now()), weight: user.UserAttr.Weight}}
+
+	return nil
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, u := range s.db.users {
+		if strings.EqualFold(u.Email, email) {
+			user := *u
+			return &user, nil
+		}
+	}
+
+	return nil, store.ErrNotFound
+}
+
+func (s *UserStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	u, ok := s.db.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	user := *u
+	return &user, nil
+}
+
+func (s *UserStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]store.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	users := make([]store.User, 0)
+	for _, u := range s.db.users {
+		if !contains(u.Username, fq.Search) && !contains(u.Email, fq.Search) {
+			continue
+		}
+		users = append(users, store.User{
+			ID:        u.ID,
+			Email:     u.Email,
+			Username:  u.Username,
+			CreatedAt: u.CreatedAt,
+			IsActive:  u.IsActive,
+			Role:      u.Role,
+			Units:     u.Units,
+		})
+	}
+	sortBy(users, fq.Sort, func(a, b store.User) bool { return a.ID < b.ID })
+
+	return page(users, fq.Limit, fq.Offset), nil
+}
+
+// Deactivate marks the user as inactive and revokes all of its sessions.
+func (s *UserStore) Deactivate(ctx context.Context, userID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.IsActive = false
+	s.db.revokeUserSessions(userID)
+
+	return nil
+}
+
+func (s *UserStore) SetRole(ctx context.Context, userID int64, role string) error {
+	switch role {
+	case store.RoleUser, store.RoleCoach, store.RoleAdmin:
+	default:
+		return fmt.Errorf("memstore: invalid role %q", role)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+
+	return nil
+}
+
+func (s *UserStore) SetUnits(ctx context.Context, userID int64, units string) error {
+	if units != "metric" && units != "imperial" {
+		return fmt.Errorf("memstore: invalid units %q", units)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Units = units
+
+	return nil
+}
+
+func (s *UserStore) Activate(ctx context.Context, plainToken string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	t, ok := s.db.invitations[hashToken(plainToken)]
+	if !ok || !t.exp.After(time.Now()) {
+		return store.ErrNotFound
+	}
+	u, ok := s.db.users[t.userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	u.IsActive = true
+	s.deleteInvitations(u.ID)
+
+	return nil
+}
+
+// Reinvite replaces the pending invitations of the user with a new one.
+func (s *UserStore) Reinvite(ctx context.Context, userID int64, token string, exp time.Duration) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.deleteInvitations(userID)
+	s.createInvite(userID, token, exp)
+
+	return nil
+}
+
+// CreatePasswordReset replaces the pending password resets of the user with a new one.
+func (s *UserStore) CreatePasswordReset(
+	ctx context.Context,
+	userID int64,
+	tokenHash string,
+	exp time.Duration,
+) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.deletePasswordResets(userID)
+	s.db.passwordResets[tokenHash] = token{userID: userID, exp: time.Now().Add(exp)}
+
+	return nil
+}
+
+// ResetPassword sets the new password hash for the user of the plain reset
+// token and revokes all sessions of the user. The token can be used only once.
+func (s *UserStore) ResetPassword(ctx context.Context, plainToken string, hash []byte) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	t, ok := s.db.passwordResets[hashToken(plainToken)]
+	if !ok || !t.exp.After(time.Now()) {
+		return store.ErrNotFound
+	}
+
+	s.updatePassword(t.userID, hash)
+	s.db.revokeUserSessions(t.userID)
+	s.deletePasswordResets(t.userID)
+
+	return nil
+}
+
+// UpdateProfile applies the profile changes of the user, every change is
+// checked before the first one is saved so a failure changes nothing.
+func (s *UserStore) UpdateProfile(ctx context.Context, userID int64, p store.ProfileUpdate) error {
+	if p.Units != nil && *p.Units != "metric" && *p.Units != "imperial" {
+		return fmt.Errorf("memstore: invalid units %q", *p.Units)
+	}
+
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.db.userAttrs[userID]; p.UserAttr != nil && !ok {
+		return store.ErrNotFound
+	}
+	if p.Username != nil {
+		if err := s.checkUnique(userID, u.Email, *p.Username); err != nil {
+			return err
+		}
+	}
+
+	if p.Username != nil {
+		u.Username = *p.Username
+	}
+	if p.Units != nil {
+		u.Units = *p.Units
+	}
+	if p.UserAttr != nil {
+		saved := copyUserAttr(p.UserAttr)
+		saved.Weight = 0
+		saved.Age = 0
+		s.db.userAttrs[userID] = saved
+	}
+	if p.PasswordHash != nil {
+		s.updatePassword(userID, p.PasswordHash)
+		s.db.revokeUserSessions(userID)
+	}
+	if ec := p.EmailChange; ec != nil {
+		for t, pending := range s.db.emailChanges {
+			if pending.userID == userID {
+				delete(s.db.emailChanges, t)
+			}
+		}
+		s.db.emailChanges[ec.Token] = emailChange{
+			token: token{userID: userID, exp: time.Now().Add(ec.Exp)},
+			email: ec.Email,
+		}
+	}
+
+	return nil
+}
+
+// ConfirmEmailChange sets the pending email of the plain token as the user
+// email. The token can be used only once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, plainToken string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	hash := hashToken(plainToken)
+	ec, ok := s.db.emailChanges[hash]
+	if !ok || !ec.exp.After(time.Now()) {
+		return store.ErrNotFound
+	}
+
+	for _, u := range s.db.users {
+		if u.ID != ec.userID && strings.EqualFold(u.Email, ec.email) {
+			return store.ErrDuplicateEmail
+		}
+	}
+
+	delete(s.db.emailChanges, hash)
+	if u, ok := s.db.users[ec.userID]; ok {
+		u.Email = ec.email
+	}
+
+	return nil
+}
+
+func (s *UserStore) AddUserWeight(ctx context.Context, userID int64, w float32) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	today := day(time.Now())
+	for _, uw := range s.db.weights[userID] {
+		if uw.date.Equal(today) {
+			return store.ErrConflict
+		}
+	}
+
+	weights := append(s.db.weights[userID], weight{date: today, weight: w})
+	sort.Slice(weights, func(i, j int) bool { return weights[i].date.Before(weights[j].date) })
+	s.db.weights[userID] = weights
+
+	return nil
+}
+
+func (s *UserStore) UpdateUserWeight(ctx context.Context, userID int64, w float32) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	today := day(time.Now())
+	for i, uw := range s.db.weights[userID] {
+		if uw.date.Equal(today) {
+			s.db.weights[userID][i].weight = w
+		}
+	}
+
+	return nil
+}
+
+// GetUserAttr returns the attributes with the last logged weight.
+func (s *UserStore) GetUserAttr(ctx context.Context, userID int64) (*store.UserAttributes, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	weights := s.db.weights[userID]
+	ua, ok := s.db.userAttrs[userID]
+	if !ok || len(weights) == 0 {
+		return nil, store.ErrNotFound
+	}
+
+	res := copyUserAttr(ua)
+	res.Weight = weights[len(weights)-1].weight
+	res.Age = store.AgeAt(res.BirthDate, time.Now())
+
+	return res, nil
+}
+
+// GetUserAttrAt returns the attributes with the weight that was current on
+// the date, falling back to the first logged weight for earlier dates.
+func (s *UserStore) GetUserAttrAt(
+	ctx context.Context,
+	userID int64,
+	date time.Time,
+) (*store.UserAttributes, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	weights := s.db.weights[userID]
+	ua, ok := s.db.userAttrs[userID]
+	if !ok || len(weights) == 0 {
+		return nil, store.ErrNotFound
+	}
+
+	res := copyUserAttr(ua)
+	res.Weight = weights[0].weight
+	for _, uw := range weights {
+		if uw.date.After(day(date)) {
+			break
+		}
+		res.Weight = uw.weight
+	}
+	res.Age = store.AgeAt(res.BirthDate, date)
+
+	return res, nil
+}
+
+// UpdateUserAttr saves the attributes except the weight, which is logged
+// separately.
+func (s *UserStore) UpdateUserAttr(ctx context.Context, ua *store.UserAttributes) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.userAttrs[ua.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	saved := copyUserAttr(ua)
+	saved.Weight = 0
+	saved.Age = 0
+	s.db.userAttrs[ua.UserID] = saved
+
+	return nil
+}
+
+func (s *UserStore) GetUserWeight(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.UserWeightByDate, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var userWeight []store.UserWeightByDate
+	for _, uw := range page(s.db.weights[userID], fq.Limit, fq.Offset) {
+		userWeight = append(userWeight, store.UserWeightByDate{Date: dateString(uw.date), Weight: uw.weight})
+	}
+
+	return userWeight, nil
+}
+
+// GetUserWeightRange returns the weight logged from since until the day
+// before until, oldest first.
+func (s *UserStore) GetUserWeightRange(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.UserWeightByDate, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return s.db.weightBetween(userID, day(since), day(until).AddDate(0, 0, -1)), nil
+}
+
+// weightBetween returns the weight logged from since to until included,
+// oldest first.
+func (d *db) weightBetween(userID int64, since, until time.Time) []store.UserWeightByDate {
+	weight := make([]store.UserWeightByDate, 0)
+	for _, uw := range d.weights[userID] {
+		if uw.date.Before(since) || uw.date.After(until) {
+			continue
+		}
+		weight = append(weight, store.UserWeightByDate{Date: dateString(uw.date), Weight: uw.weight})
+	}
+
+	return weight
+}
+
+// checkUnique returns the error of the unique constraints of the users table
+// when another user than id has the email or the username.
+func (s *UserStore) checkUnique(id int64, email, username string) error {
+	for _, u := range s.db.users {
+		if u.ID != id && strings.EqualFold(u.Email, email) {
+			return store.ErrDuplicateEmail
+		}
+	}
+	for _, u := range s.db.users {
+		if u.ID != id && u.Username == username {
+			return store.ErrDuplicateUsername
+		}
+	}
+
+	return nil
+}
+
+func (s *UserStore) createInvite(userID int64, tokenHash string, exp time.Duration) {
+	s.db.invitations[tokenHash] = token{userID: userID, exp: time.Now().Add(exp)}
+}
+
+func (s *UserStore) deleteInvitations(userID int64) {
+	for t, inv := range s.db.invitations {
+		if inv.userID == userID {
+			delete(s.db.invitations, t)
+		}
+	}
+}
+
+func (s *UserStore) deletePasswordResets(userID int64) {
+	for t, reset := range s.db.passwordResets {
+		if reset.userID == userID {
+			delete(s.db.passwordResets, t)
+		}
+	}
+}
+
+func (s *UserStore) updatePassword(userID int64, hash []byte) {
+	u, ok := s.db.users[userID]
+	if !ok {
+		return
+	}
+
+	u.Password.Hash = hash
+	u.PasswordChangedAt.Time = time.Now()
+	u.PasswordChangedAt.Valid = true
+}
+
+func copyUserAttr(ua *store.UserAttributes) *store.UserAttributes {
+	res := *ua
+	if ua.BirthDate != nil {
+		birthDate := *ua.BirthDate
+		res.BirthDate = &birthDate
+	}
+	if ua.BodyFat != nil {
+		bodyFat := *ua.BodyFat
+		res.BodyFat = &bodyFat
+	}
+	if ua.MacroRatios != nil {
+		ratios := *ua.MacroRatios
+		res.MacroRatios = &ratios
+	}
+	return &res
+}
@@ -0,0 +1,281 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type WorkoutStore struct {
+	db *db
+}
+
+func (s *WorkoutStore) GetAll(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Workout, int, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	workouts := s.list(fq, userID, func(w *store.Workout) bool {
+		return s.matches(w, fq.Search, fq.Tags)
+	})
+	total := len(workouts)
+
+	return page(workouts, fq.Limit, fq.Offset), total, nil
+}
+
+func (s *WorkoutStore) GetUsersWorkouts(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Workout, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	workouts := s.list(fq, userID, func(w *store.Workout) bool {
+		return w.UserID == userID
+	})
+
+	return page(workouts, fq.Limit, fq.Offset), nil
+}
+
+func (s *WorkoutStore) Create(ctx context.Context, w *store.Workout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[w.UserID]; !ok {
+		return store.ErrNotFound
+	}
+	if err := s.checkExercises(w.WorkoutExercises); err != nil {
+		return err
+	}
+
+	w.ID = s.db.nextID("workouts")
+	w.CreatedAt = timestamp()
+	s.db.workouts[w.ID] = &store.Workout{
+		ID:           w.ID,
+		UserID:       w.UserID,
+		Name:         w.Name,
+		Description:  w.Description,
+		TutorialLink: w.TutorialLink,
+		CreatedAt:    w.CreatedAt,
+	}
+	s.setExercises(w)
+
+	return nil
+}
+
+// Update changes the workout details and, when WorkoutExercises is not nil,
+// replaces the workout exercises with the given ones in their slice order.
+func (s *WorkoutStore) Update(ctx context.Context, w *store.Workout) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	saved, ok := s.db.workouts[w.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if err := s.checkExercises(w.WorkoutExercises); err != nil {
+		return err
+	}
+
+	saved.Name = w.Name
+	saved.Description = w.Description
+	saved.TutorialLink = w.TutorialLink
+	if w.WorkoutExercises != nil {
+		s.setExercises(w)
+	}
+
+	return nil
+}
+
+// Delete removes the workout together with its exercises, likes, reviews
+// and finished workouts.
+func (s *WorkoutStore) Delete(ctx context.Context, id int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.workouts[id]; !ok {
+		return store.ErrNotFound
+	}
+
+	for l := range s.db.workoutLikes {
+		if l.id == id {
+			delete(s.db.workoutLikes, l)
+		}
+	}
+	for k := range s.db.reviews {
+		if k.id == id {
+			delete(s.db.reviews, k)
+		}
+	}
+	for fwID, fw := range s.db.finishedWorkouts {
+		if fw.WorkoutID == id {
+			s.db.deleteFinishedWorkout(fwID)
+		}
+	}
+	for awID, aw := range s.db.activeWorkouts {
+		if aw.WorkoutID == id {
+			delete(s.db.activeWorkouts, awID)
+		}
+	}
+	delete(s.db.workoutExercises, id)
+	delete(s.db.workouts, id)
+
+	return nil
+}
+
+func (s *WorkoutStore) GetByID(ctx context.Context, id int64) (*store.Workout, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	w, ok := s.db.workouts[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	res := s.withStats(w, 0)
+	res.UserLiked = false
+	res.IsHidden = w.IsHidden
+	return &res, nil
+}
+
+func (s *WorkoutStore) GetWorkoutExercises(
+	ctx context.Context,
+	workoutID int64,
+) ([]store.WorkoutExercises, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	workoutExercises := make([]store.WorkoutExercises, 0)
+	for _, we := range s.db.workoutExercises[workoutID] {
+		e, ok := s.db.exercises[we.ExerciseID]
+		if !ok {
+			continue
+		}
+		we.Exercise = copyExercise(e)
+		workoutExercises = append(workoutExercises, we)
+	}
+
+	return workoutExercises, nil
+}
+
+func (s *WorkoutStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	w, ok := s.db.workouts[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	w.IsHidden = hidden
+
+	return nil
+}
+
+// list returns the visible workouts matching the filter with their likes and
+// rating, ordered by likes.
+func (s *WorkoutStore) list(
+	fq pagination.PaginatedQuery,
+	userID int64,
+	match func(*store.Workout) bool,
+) []store.Workout {
+	workouts := make([]store.Workout, 0)
+	for _, w := range s.db.workouts {
+		if w.IsHidden || !match(w) {
+			continue
+		}
+		workouts = append(workouts, s.withStats(w, userID))
+	}
+	sortBy(workouts, "asc", func(a, b store.Workout) bool { return a.ID < b.ID })
+	sortBy(workouts, fq.Sort, func(a, b store.Workout) bool { return a.Likes < b.Likes })
+
+	return workouts
+}
+
+// matches filters like the joins of the Postgres query: one of the workout
+// exercises, or the workout itself when it has none, has to match both the
+// search and the muscles.
+func (s *WorkoutStore) matches(w *store.Workout, search string, muscles []string) bool {
+	workoutMatches := contains(w.Name, search) || contains(w.Description, search)
+
+	wes := s.db.workoutExercises[w.ID]
+	if len(wes) == 0 {
+		return workoutMatches && len(muscles) == 0
+	}
+
+	for _, we := range wes {
+		e, ok := s.db.exercises[we.ExerciseID]
+		if !ok {
+			continue
+		}
+		if (workoutMatches || contains(e.Name, search) || contains(e.Description, search)) &&
+			containsAll(e.Muscles, muscles) {
+			return true
+		}
+	}
+	return false
+}
+
+// withStats copies the workout with its likes, visible reviews count and
+// average rating. UserLiked is set when userID liked the workout.
+func (s *WorkoutStore) withStats(w *store.Workout, userID int64) store.Workout {
+	res := store.Workout{
+		ID:           w.ID,
+		UserID:       w.UserID,
+		Name:         w.Name,
+		Description:  w.Description,
+		TutorialLink: w.TutorialLink,
+		CreatedAt:    w.CreatedAt,
+	}
+
+	for l := range s.db.workoutLikes {
+		if l.id == w.ID {
+			res.Likes++
+			res.UserLiked = res.UserLiked || l.userID == userID
+		}
+	}
+
+	var ratings int
+	for _, r := range s.db.reviews {
+		if r.WorkoutID == w.ID && !r.hidden {
+			res.ReviewsCount++
+			ratings += r.Rating
+		}
+	}
+	if res.ReviewsCount > 0 {
+		res.Rating = float32(float64(ratings) / float64(res.ReviewsCount))
+	}
+
+	return res
+}
+
+func (s *WorkoutStore) checkExercises(wes []store.WorkoutExercises) error {
+	for _, we := range wes {
+		if _, ok := s.db.exercises[we.ExerciseID]; !ok {
+			return store.ErrNotFound
+		}
+	}
+	return nil
+}
+
+// setExercises replaces the exercises of the workout, positioned in slice
+// order. An exercise given twice is only added once.
+func (s *WorkoutStore) setExercises(w *store.Workout) {
+	w.WorkoutExercises = store.UniqueExercises(w.WorkoutExercises)
+	saved := make([]store.WorkoutExercises, 0, len(w.WorkoutExercises))
+	for i := range w.WorkoutExercises {
+		we := &w.WorkoutExercises[i]
+		we.WorkoutID = w.ID
+		saved = append(saved, store.WorkoutExercises{
+			WorkoutID:  w.ID,
+			ExerciseID: we.ExerciseID,
+			Duration:   we.Duration,
+			Position:   we.Position,
+		})
+	}
+	s.db.workoutExercises[w.ID] = saved
+}
@@ -0,0 +1,298 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+)
+
+const (
+	RecordWeight       = "weight"
+	RecordEstimated1RM = "estimated_1rm"
+	RecordReps         = "reps"
+	RecordDuration     = "duration"
+)
+
+// PersonalRecord is the best value a user reached on an exercise for one
+// kind of record. Previous is only set on records broken by a new session.
+// FinishedWorkoutID is nil when the workout which set the record was deleted,
+// the record itself is kept.
+type PersonalRecord struct {
+	UserID            int64     `json:"user_id"`
+	ExerciseID        int64     `json:"exercise_id"`
+	ExerciseName      string    `json:"exercise_name,omitempty"`
+	Kind              string    `json:"kind"`
+	Value             float32   `json:"value"`
+	Previous          *float32  `json:"previous,omitempty"`
+	FinishedWorkoutID *int64    `json:"finished_workout_id"`
+	AchievedAt        time.Time `json:"achieved_at"`
+}
+
+// ExerciseProgress sums up the sets of one exercise in a finished workout.
+type ExerciseProgress struct {
+	FinishedWorkoutID int64     `json:"finished_workout_id"`
+	Date              time.Time `json:"date"`
+	Sets              int       `json:"sets"`
+	MaxWeight         float32   `json:"max_weight"`
+	Estimated1RM      float32   `json:"estimated_1rm"`
+	MaxReps           int       `json:"max_reps"`
+	MaxDuration       int       `json:"max_duration"`
+	Volume            float32   `json:"volume"`
+}
+
+type PersonalRecordsStore struct {
+	db *sql.DB
+}
+
+func (s *PersonalRecordsStore) GetByUserID(ctx context.Context, userID int64) ([]PersonalRecord, error) {
+	query := `
+		SELECT pr.user_id, pr.exercise_id, e.name, pr.kind, pr.value, pr.finished_workout_id, pr.achieved_at
+		FROM personal_records pr
+		JOIN exercises e ON e.id = pr.exercise_id
+		WHERE pr.user_id = $1
+		ORDER BY e.name, pr.kind
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]PersonalRecord, 0)
+	for rows.Next() {
+		var pr PersonalRecord
+		err := rows.Scan(
+			&pr.UserID,
+			&pr.ExerciseID,
+			&pr.ExerciseName,
+			&pr.Kind,
+			&pr.Value,
+			&pr.FinishedWorkoutID,
+			&pr.AchievedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, pr)
+	}
+
+	return records, rows.Err()
+}
+
+// GetProgress returns the user's sessions containing the exercise, one point
+// per finished workout, within the since and until bounds of the query.
+func (s *PersonalRecordsStore) GetProgress(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID, exerciseID int64,
+) ([]ExerciseProgress, error) {
+	query := `
+		SELECT fw.id, fw.ended_at, COUNT(*), MAX(fs.weight),
+		  MAX(CASE WHEN fs.reps = 1 THEN fs.weight WHEN fs.reps > 1 THEN fs.weight * (1 + fs.reps / 30.0) ELSE 0 END),
+		  MAX(fs.reps), MAX(fs.duration), SUM(fs.weight * fs.reps)
+		FROM finished_workout_sets fs
+		JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		WHERE fw.user_id = $1 AND fs.exercise_id = $2 AND
+		  ($3 = '' OR fw.ended_at >= $3::timestamptz) AND
+		  ($4 = '' OR fw.ended_at <= $4::timestamptz)
+		GROUP BY fw.id
+		ORDER BY fw.ended_at ` + fq.Sort + `
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userID,
+		exerciseID,
+		fq.Since,
+		fq.Until,
+		fq.Limit,
+		fq.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := make([]ExerciseProgress, 0)
+	for rows.Next() {
+		var p ExerciseProgress
+		err := rows.Scan(
+			&p.FinishedWorkoutID,
+			&p.Date,
+			&p.Sets,
+			&p.MaxWeight,
+			&p.Estimated1RM,
+			&p.MaxReps,
+			&p.MaxDuration,
+			&p.Volume,
+		)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, rows.Err()
+}
+
+// Estimated1RM estimates the one repetition maximum with the Epley formula.
+// The GetProgress queries compute the same estimate in SQL.
+func Estimated1RM(weight float32, reps int) float32 {
+	if reps <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + float32(reps)/30)
+}
+
+// updateRecords compares the sets of the finished workout with the stored
+// records and saves the ones it beats. It returns the new records.
+func updateRecords(ctx context.Context, tx *sql.Tx, fn *FinishedWorkout) ([]PersonalRecord, error) {
+	if len(fn.Sets) == 0 {
+		return nil, nil
+	}
+
+	isDuration, err := exercisesIsDuration(ctx, tx, fn.Sets)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]PersonalRecord, 0)
+	for _, pr := range RecordCandidates(fn, isDuration) {
+		isNew, err := saveRecord(ctx, tx, &pr)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			records = append(records, pr)
+		}
+	}
+
+	return records, nil
+}
+
+// RecordCandidates returns the best value of every record kind reached by
+// the sets of the finished workout, in the order the exercises were trained.
+// Duration records are kept for the exercises of isDuration, the other kinds
+// for the rest.
+func RecordCandidates(fn *FinishedWorkout, isDuration map[int64]bool) []PersonalRecord {
+	best := make(map[int64]map[string]float32)
+	order := make([]int64, 0)
+	for _, ws := range fn.Sets {
+		if _, ok := best[ws.ExerciseID]; !ok {
+			best[ws.ExerciseID] = make(map[string]float32)
+			order = append(order, ws.ExerciseID)
+		}
+		b := best[ws.ExerciseID]
+		if isDuration[ws.ExerciseID] {
+			b[RecordDuration] = max(b[RecordDuration], float32(ws.Duration))
+			continue
+		}
+		b[RecordWeight] = max(b[RecordWeight], ws.Weight)
+		b[RecordEstimated1RM] = max(b[RecordEstimated1RM], Estimated1RM(ws.Weight, ws.Reps))
+		b[RecordReps] = max(b[RecordReps], float32(ws.Reps))
+	}
+
+	finishedWorkoutID := fn.ID
+	candidates := make([]PersonalRecord, 0)
+	for _, exerciseID := range order {
+		for _, kind := range []string{RecordWeight, RecordEstimated1RM, RecordReps, RecordDuration} {
+			value, ok := best[exerciseID][kind]
+			if !ok || value <= 0 {
+				continue
+			}
+
+			candidates = append(candidates, PersonalRecord{
+				UserID:            fn.UserID,
+				ExerciseID:        exerciseID,
+				Kind:              kind,
+				Value:             value,
+				FinishedWorkoutID: &finishedWorkoutID,
+				AchievedAt:        fn.EndedAt,
+			})
+		}
+	}
+
+	return candidates
+}
+
+func exercisesIsDuration(ctx context.Context, tx *sql.Tx, sets []WorkoutSet) (map[int64]bool, error) {
+	ids := make([]int64, 0, len(sets))
+	for _, ws := range sets {
+		ids = append(ids, ws.ExerciseID)
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, is_duration FROM exercises WHERE id = ANY($1)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	isDuration := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		var d bool
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, err
+		}
+		isDuration[id] = d
+	}
+
+	return isDuration, rows.Err()
+}
+
+// saveRecord stores the record when it beats the current one and reports
+// whether it did, filling Previous with the beaten value.
+func saveRecord(ctx context.Context, tx *sql.Tx, pr *PersonalRecord) (bool, error) {
+	query := `
+		SELECT value FROM personal_records
+		WHERE user_id = $1 AND exercise_id = $2 AND kind = $3
+		FOR UPDATE
+	`
+	var previous float32
+	err := tx.QueryRowContext(ctx, query, pr.UserID, pr.ExerciseID, pr.Kind).Scan(&previous)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	case previous >= pr.Value:
+		return false, nil
+	default:
+		pr.Previous = &previous
+	}
+
+	query = `
+		INSERT INTO personal_records (user_id, exercise_id, kind, value, finished_workout_id, achieved_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, exercise_id, kind) DO UPDATE
+		SET value = EXCLUDED.value, finished_workout_id = EXCLUDED.finished_workout_id,
+		  achieved_at = EXCLUDED.achieved_at
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		pr.UserID,
+		pr.ExerciseID,
+		pr.Kind,
+		pr.Value,
+		pr.FinishedWorkoutID,
+		pr.AchievedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -3,6 +3,9 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
 )
 
 type WorkoutReview struct {
@@ -30,9 +33,56 @@ func (s *ReviewsStore) CreateWorkout(ctx context.Context, wr *WorkoutReview) err
   `
 	err := s.db.QueryRowContext(ctx, query, wr.UserID, wr.WorkoutID, wr.Rating, wr.Title, wr.Content).
 		Scan(&wr.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23505":
+				return ErrConflict
+			case "23503":
+				return ErrNotFound
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *ReviewsStore) Update(ctx context.Context, wr *WorkoutReview) error {
+	query := `
+    UPDATE workout_reviews SET rating = $1, title = $2, content = $3
+    WHERE user_id = $4 AND workout_id = $5
+    RETURNING created_at
+  `
+	err := s.db.QueryRowContext(ctx, query, wr.Rating, wr.Title, wr.Content, wr.UserID, wr.WorkoutID).
+		Scan(&wr.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *ReviewsStore) Delete(ctx context.Context, userID, workoutID int64) error {
+	query := `
+    DELETE FROM workout_reviews WHERE user_id = $1 AND workout_id = $2
+  `
+	res, err := s.db.ExecContext(ctx, query, userID, workoutID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if rows == 0 {
+		return ErrNotFound
+	}
 
 	return nil
 }
@@ -44,7 +94,7 @@ func (s *ReviewsStore) Get(
 	query := `
     SELECT user_id, workout_id, rating, title, content, wr.created_at, u.username FROM workout_reviews wr
     LEFT JOIN  users u ON u.ID = wr.user_id
-    WHERE workout_id = $1
+    WHERE workout_id = $1 AND NOT wr.is_hidden
   `
 	rows, err := s.db.QueryContext(ctx, query, workoutID)
 	if err != nil {
@@ -71,3 +121,10 @@ func (s *ReviewsStore) Get(
 	}
 	return workoutReviews, nil
 }
+
+func (s *ReviewsStore) SetHidden(ctx context.Context, userID, workoutID int64, hidden bool) error {
+	query := `
+    UPDATE workout_reviews SET is_hidden = $1 WHERE user_id = $2 AND workout_id = $3
+  `
+	return execAffectingOne(ctx, s.db, query, hidden, userID, workoutID)
+}
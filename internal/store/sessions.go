@@ -0,0 +1,128 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type Session struct {
+	ID           string       `json:"id"`
+	UserID       int64        `json:"user_id"`
+	RefreshToken string       `json:"-"`
+	Exp          time.Time    `json:"exp"`
+	CreatedAt    string       `json:"created_at"`
+	RevokedAt    sql.NullTime `json:"-"`
+}
+
+type SessionsStore struct {
+	db *sql.DB
+}
+
+func (s *SessionsStore) Create(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, refresh_token, exp) VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, session.ID, session.UserID, session.RefreshToken, session.Exp).
+		Scan(&session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionsStore) GetByID(ctx context.Context, id string) (*Session, error) {
+	query := `
+		SELECT id, user_id, refresh_token, exp, created_at, revoked_at FROM sessions WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	session := &Session{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.Exp,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Rotate replaces the refresh token of an active session and extends its
+// expiration. The old refresh token can not be used anymore.
+func (s *SessionsStore) Rotate(
+	ctx context.Context,
+	oldToken string,
+	newToken string,
+	exp time.Time,
+) (*Session, error) {
+	query := `
+		UPDATE sessions SET refresh_token = $1, exp = $2
+		WHERE refresh_token = $3 AND revoked_at IS NULL AND exp > $4
+		RETURNING id, user_id, created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
+	defer cancel()
+
+	session := &Session{RefreshToken: newToken, Exp: exp}
+	err := s.db.QueryRowContext(ctx, query, newToken, exp, oldToken, time.Now()).
+		Scan(&session.ID, &session.UserID, &session.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *SessionsStore) Revoke(ctx context.Context, id string) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionsStore) RevokeAll(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return revokeUserSessions(ctx, tx, userID)
+	})
+}
+
+func revokeUserSessions(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
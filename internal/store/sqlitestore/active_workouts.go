@@ -0,0 +1,206 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type ActiveWorkoutsStore struct {
+	db *sql.DB
+}
+
+// Start begins a new session. It returns ErrConflict when the user already has
+// one and ErrNotFound when the workout does not exist.
+func (s *ActiveWorkoutsStore) Start(ctx context.Context, aw *store.ActiveWorkout) error {
+	query := `
+		INSERT INTO active_workouts (user_id, workout_id) VALUES ($1, $2)
+		RETURNING id, started_at, updated_at
+	`
+	err := s.db.QueryRowContext(ctx, query, aw.UserID, aw.WorkoutID).
+		Scan(&aw.ID, &aw.StartedAt, &aw.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return fkViolationAsNotFound(err)
+	}
+
+	aw.Sets = make([]store.WorkoutSet, 0)
+	return nil
+}
+
+// GetByUserID returns the session of the user together with its sets.
+func (s *ActiveWorkoutsStore) GetByUserID(ctx context.Context, userID int64) (*store.ActiveWorkout, error) {
+	query := `
+		SELECT id, user_id, workout_id, started_at, paused_at, paused_duration, updated_at
+		FROM active_workouts
+		WHERE user_id = $1
+	`
+	aw := &store.ActiveWorkout{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&aw.ID,
+		&aw.UserID,
+		&aw.WorkoutID,
+		&aw.StartedAt,
+		&aw.PausedAt,
+		&aw.PausedDuration,
+		&aw.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	sets, err := s.getSets(ctx, aw.ID)
+	if err != nil {
+		return nil, err
+	}
+	aw.Sets = sets
+
+	return aw, nil
+}
+
+// Pause returns ErrConflict when the session is already paused.
+func (s *ActiveWorkoutsStore) Pause(ctx context.Context, id int64) error {
+	query := `
+		UPDATE active_workouts SET paused_at = $2, updated_at = $2
+		WHERE id = $1 AND paused_at IS NULL
+	`
+	if err := execAffectingOne(ctx, s.db, query, id, timestamp(time.Now())); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return store.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// Resume returns ErrConflict when the session is not paused.
+func (s *ActiveWorkoutsStore) Resume(ctx context.Context, id int64) error {
+	query := `
+		UPDATE active_workouts
+		SET paused_duration = paused_duration + CAST(ROUND((julianday($2) - julianday(paused_at)) * 86400) AS INTEGER),
+		  paused_at = NULL,
+		  updated_at = $2
+		WHERE id = $1 AND paused_at IS NOT NULL
+	`
+	if err := execAffectingOne(ctx, s.db, query, id, timestamp(time.Now())); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return store.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// AddSet appends the set to the session, numbering it after the previous sets
+// of the same exercise.
+func (s *ActiveWorkoutsStore) AddSet(ctx context.Context, activeWorkoutID int64, ws *store.WorkoutSet) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO active_workout_sets (active_workout_id, exercise_id, set_number, reps, weight, duration, rpe)
+			VALUES (
+			  $1,
+			  $2,
+			  (SELECT COALESCE(MAX(set_number), 0) + 1 FROM active_workout_sets WHERE active_workout_id = $1 AND exercise_id = $2),
+			  $3, $4, $5, $6
+			)
+			RETURNING id, set_number
+		`
+		err := tx.QueryRowContext(ctx, query, activeWorkoutID, ws.ExerciseID, ws.Reps, ws.Weight, ws.Duration, ws.RPE).
+			Scan(&ws.ID, &ws.SetNumber)
+		if err != nil {
+			return fkViolationAsNotFound(err)
+		}
+
+		query = `UPDATE active_workouts SET updated_at = $1 WHERE id = $2`
+		_, err = tx.ExecContext(ctx, query, timestamp(time.Now()), activeWorkoutID)
+		return err
+	})
+}
+
+// Finish removes the session and saves it as a finished workout ending at
+// endedAt, it returns store.ErrNotFound when the session was already removed.
+func (s *ActiveWorkoutsStore) Finish(
+	ctx context.Context,
+	aw *store.ActiveWorkout,
+	endedAt time.Time,
+) (*store.FinishedWorkout, error) {
+	fn := &store.FinishedWorkout{
+		UserID:    aw.UserID,
+		WorkoutID: aw.WorkoutID,
+		StartedAt: aw.StartedAt,
+		EndedAt:   endedAt,
+		Duration:  int(aw.Duration(endedAt).Seconds()),
+		Sets:      aw.Sets,
+	}
+
+	err := withTx(s.db, ctx, func(tx *sql.Tx) error {
+		err := execAffectingOne(ctx, tx, `DELETE FROM active_workouts WHERE id = $1`, aw.ID)
+		if err != nil {
+			return err
+		}
+
+		return (&FinishedWorkoutsStore{s.db}).create(ctx, tx, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+func (s *ActiveWorkoutsStore) Delete(ctx context.Context, id int64) error {
+	return execAffectingOne(ctx, s.db, `DELETE FROM active_workouts WHERE id = $1`, id)
+}
+
+// DeleteStale removes sessions which were not updated since before and
+// returns how many were removed.
+func (s *ActiveWorkoutsStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM active_workouts WHERE updated_at < $1`, timestamp(before))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *ActiveWorkoutsStore) getSets(ctx context.Context, activeWorkoutID int64) ([]store.WorkoutSet, error) {
+	query := `
+		SELECT id, exercise_id, set_number, reps, weight, duration, rpe
+		FROM active_workout_sets
+		WHERE active_workout_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query, activeWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]store.WorkoutSet, 0)
+	for rows.Next() {
+		var ws store.WorkoutSet
+		err := rows.Scan(
+			&ws.ID,
+			&ws.ExerciseID,
+			&ws.SetNumber,
+			&ws.Reps,
+			&ws.Weight,
+			&ws.Duration,
+			&ws.RPE,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, ws)
+	}
+
+	return sets, rows.Err()
+}
@@ -0,0 +1,215 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type ExerciseStore struct {
+	db *sql.DB
+}
+
+func (s *ExerciseStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]store.Exercise, error) {
+	query := `
+		SELECT e.id, e.user_id, e.name, e.description, e.is_duration, e.duration, e.tutorial_link,
+		  e.created_at, e.muscles, COUNT(DISTINCT el.user_id) AS likes
+		FROM exercises e
+		LEFT JOIN exercise_likes el ON e.id = el.exercise_id
+		WHERE (e.name LIKE '%' || $1 || '%' OR e.description LIKE '%' || $1 || '%') AND
+		  ` + containsMuscles("e.muscles", "$2") + ` AND NOT e.is_hidden
+		GROUP BY e.id
+		ORDER BY likes ` + fq.Sort + `
+		LIMIT $3 OFFSET $4
+	`
+	return s.list(ctx, query, fq.Search, array(&fq.Tags), fq.Limit, fq.Offset)
+}
+
+func (s *ExerciseStore) Create(ctx context.Context, e *store.Exercise) error {
+	query := `
+		INSERT INTO exercises (user_id, name, description, is_duration, duration, tutorial_link, muscles)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		e.UserID,
+		e.Name,
+		e.Description,
+		e.IsDuration,
+		e.Duration,
+		e.TutorialLink,
+		array(&e.Muscles),
+	).Scan(&e.ID, &e.CreatedAt)
+	return fkViolationAsNotFound(err)
+}
+
+func (s *ExerciseStore) GetByID(ctx context.Context, id int64) (*store.Exercise, error) {
+	query := `
+		SELECT user_id, name, description, is_duration, duration, tutorial_link, muscles, created_at, is_hidden
+		FROM exercises WHERE id = $1
+	`
+	e := &store.Exercise{ID: id}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&e.UserID,
+		&e.Name,
+		&e.Description,
+		&e.IsDuration,
+		&e.Duration,
+		&e.TutorialLink,
+		array(&e.Muscles),
+		&e.CreatedAt,
+		&e.IsHidden,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (s *ExerciseStore) GetUsersExercises(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Exercise, error) {
+	query := `
+		SELECT e.id, e.user_id, e.name, e.description, e.is_duration, e.duration, e.tutorial_link,
+		  e.created_at, e.muscles, COUNT(DISTINCT el.user_id) AS likes
+		FROM exercises e
+		LEFT JOIN exercise_likes el ON e.id = el.exercise_id
+		WHERE e.user_id = $1 AND NOT e.is_hidden
+		GROUP BY e.id
+		ORDER BY likes ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	return s.list(ctx, query, userID, fq.Limit, fq.Offset)
+}
+
+func (s *ExerciseStore) Update(ctx context.Context, e *store.Exercise) error {
+	query := `
+		UPDATE exercises
+		SET name = $1, description = $2, is_duration = $3, duration = $4, tutorial_link = $5, muscles = $6
+		WHERE id = $7
+	`
+	return execAffectingOne(
+		ctx,
+		s.db,
+		query,
+		e.Name,
+		e.Description,
+		e.IsDuration,
+		e.Duration,
+		e.TutorialLink,
+		array(&e.Muscles),
+		e.ID,
+	)
+}
+
+// Delete removes the exercise and its likes. It returns ErrConflict when the
+// exercise is still part of a workout or of logged sets.
+func (s *ExerciseStore) Delete(ctx context.Context, id int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM exercise_likes WHERE exercise_id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM exercises WHERE id = $1`, id)
+		if err != nil {
+			if isFKViolation(err) {
+				return store.ErrConflict
+			}
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// GetDependentWorkouts returns the workouts which include the exercise or
+// which have logged sets of it, finished or in progress.
+func (s *ExerciseStore) GetDependentWorkouts(ctx context.Context, id int64) ([]store.Workout, error) {
+	query := `
+		SELECT w.id, w.user_id, w.name, w.description, w.tutorial_link, w.created_at FROM workouts w
+		WHERE EXISTS (
+		  SELECT 1 FROM workout_exercises we WHERE we.workout_id = w.id AND we.exercise_id = $1
+		) OR EXISTS (
+		  SELECT 1 FROM finished_workout_sets fs
+		  JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		  WHERE fw.workout_id = w.id AND fs.exercise_id = $1
+		) OR EXISTS (
+		  SELECT 1 FROM active_workout_sets aws
+		  JOIN active_workouts aw ON aw.id = aws.active_workout_id
+		  WHERE aw.workout_id = w.id AND aws.exercise_id = $1
+		)
+		ORDER BY w.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := make([]store.Workout, 0)
+	for rows.Next() {
+		var w store.Workout
+		err := rows.Scan(&w.ID, &w.UserID, &w.Name, &w.Description, &w.TutorialLink, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
+}
+
+func (s *ExerciseStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	return execAffectingOne(ctx, s.db, `UPDATE exercises SET is_hidden = $1 WHERE id = $2`, hidden, id)
+}
+
+func (s *ExerciseStore) list(ctx context.Context, query string, args ...any) ([]store.Exercise, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exercises []store.Exercise
+	for rows.Next() {
+		var e store.Exercise
+		err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Name,
+			&e.Description,
+			&e.IsDuration,
+			&e.Duration,
+			&e.TutorialLink,
+			&e.CreatedAt,
+			array(&e.Muscles),
+			&e.Likes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, e)
+	}
+
+	return exercises, rows.Err()
+}
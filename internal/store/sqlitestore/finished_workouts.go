@@ -0,0 +1,190 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type FinishedWorkoutsStore struct {
+	db *sql.DB
+}
+
+func (s *FinishedWorkoutsStore) Create(ctx context.Context, fn *store.FinishedWorkout) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.create(ctx, tx, fn)
+	})
+}
+
+func (s *FinishedWorkoutsStore) GetAll(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.FinishedWorkout, int, error) {
+	query := `
+		SELECT fw.id, fw.user_id, fw.workout_id, w.name, fw.started_at, fw.ended_at, fw.duration,
+		  COUNT(*) OVER() AS total_count
+		FROM finished_workouts fw
+		JOIN workouts w ON w.id = fw.workout_id
+		WHERE fw.user_id = $1
+		ORDER BY fw.ended_at ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	finisedWorkouts := make([]store.FinishedWorkout, 0)
+	for rows.Next() {
+		var fn store.FinishedWorkout
+		err := rows.Scan(
+			&fn.ID,
+			&fn.UserID,
+			&fn.WorkoutID,
+			&fn.WorkoutName,
+			&fn.StartedAt,
+			&fn.EndedAt,
+			&fn.Duration,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		finisedWorkouts = append(finisedWorkouts, fn)
+	}
+
+	return finisedWorkouts, totalCount, rows.Err()
+}
+
+// GetByID returns the finished workout together with its sets.
+func (s *FinishedWorkoutsStore) GetByID(ctx context.Context, id int64) (*store.FinishedWorkout, error) {
+	query := `
+		SELECT fw.id, fw.user_id, fw.workout_id, w.name, fw.started_at, fw.ended_at, fw.duration
+		FROM finished_workouts fw
+		JOIN workouts w ON w.id = fw.workout_id
+		WHERE fw.id = $1
+	`
+	fn := &store.FinishedWorkout{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&fn.ID,
+		&fn.UserID,
+		&fn.WorkoutID,
+		&fn.WorkoutName,
+		&fn.StartedAt,
+		&fn.EndedAt,
+		&fn.Duration,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	sets, err := s.getSets(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fn.Sets = sets
+
+	return fn, nil
+}
+
+func (s *FinishedWorkoutsStore) getSets(ctx context.Context, finishedWorkoutID int64) ([]store.WorkoutSet, error) {
+	query := `
+		SELECT id, finished_workout_id, exercise_id, set_number, reps, weight, duration, rpe
+		FROM finished_workout_sets
+		WHERE finished_workout_id = $1
+		ORDER BY id
+	`
+	rows, err := s.db.QueryContext(ctx, query, finishedWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make([]store.WorkoutSet, 0)
+	for rows.Next() {
+		var ws store.WorkoutSet
+		err := rows.Scan(
+			&ws.ID,
+			&ws.FinishedWorkoutID,
+			&ws.ExerciseID,
+			&ws.SetNumber,
+			&ws.Reps,
+			&ws.Weight,
+			&ws.Duration,
+			&ws.RPE,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, ws)
+	}
+
+	return sets, rows.Err()
+}
+
+// create saves the finished workout with its sets and updates the personal
+// records it beats.
+func (s *FinishedWorkoutsStore) create(ctx context.Context, tx *sql.Tx, fn *store.FinishedWorkout) error {
+	query := `
+		INSERT INTO finished_workouts (user_id, workout_id, started_at, ended_at, duration)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		fn.UserID,
+		fn.WorkoutID,
+		timestamp(fn.StartedAt),
+		timestamp(fn.EndedAt),
+		fn.Duration,
+	).Scan(&fn.ID)
+	if err != nil {
+		return fkViolationAsNotFound(err)
+	}
+
+	for i := range fn.Sets {
+		fn.Sets[i].ID = 0
+		fn.Sets[i].FinishedWorkoutID = fn.ID
+		if err := s.addSet(ctx, tx, &fn.Sets[i]); err != nil {
+			return err
+		}
+	}
+
+	records, err := updateRecords(ctx, tx, fn)
+	if err != nil {
+		return err
+	}
+	fn.NewRecords = records
+
+	return nil
+}
+
+func (s *FinishedWorkoutsStore) addSet(ctx context.Context, tx *sql.Tx, ws *store.WorkoutSet) error {
+	query := `
+		INSERT INTO finished_workout_sets (finished_workout_id, exercise_id, set_number, reps, weight, duration, rpe)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		ws.FinishedWorkoutID,
+		ws.ExerciseID,
+		ws.SetNumber,
+		ws.Reps,
+		ws.Weight,
+		ws.Duration,
+		ws.RPE,
+	).Scan(&ws.ID)
+	return fkViolationAsNotFound(err)
+}
@@ -0,0 +1,151 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/trigram"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type FoodsStore struct {
+	db *sql.DB
+}
+
+const foodColumns = `id, user_id, barcode, name, calories, proteins, fats, carbohydrates, created_at`
+
+func (s *FoodsStore) Create(ctx context.Context, f *store.Food) error {
+	query := `
+		INSERT INTO foods (user_id, barcode, name, calories, proteins, fats, carbohydrates)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		f.UserID,
+		f.Barcode,
+		f.Name,
+		f.Calories,
+		f.Proteins,
+		f.Fats,
+		f.Carbohydrates,
+	).Scan(&f.ID, &f.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Upsert inserts the foods in one transaction, foods with a known barcode
+// are updated instead.
+func (s *FoodsStore) Upsert(ctx context.Context, foods []store.Food) error {
+	query := `
+		INSERT INTO foods (barcode, name, calories, proteins, fats, carbohydrates)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (barcode) DO UPDATE
+		SET name = excluded.name, calories = excluded.calories, proteins = excluded.proteins,
+		  fats = excluded.fats, carbohydrates = excluded.carbohydrates
+	`
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, f := range foods {
+			_, err := stmt.ExecContext(ctx, f.Barcode, f.Name, f.Calories, f.Proteins, f.Fats, f.Carbohydrates)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *FoodsStore) GetByID(ctx context.Context, id int64) (*store.Food, error) {
+	query := `SELECT ` + foodColumns + ` FROM foods WHERE id = $1`
+	return s.get(ctx, query, id)
+}
+
+func (s *FoodsStore) GetByBarcode(ctx context.Context, barcode string) (*store.Food, error) {
+	query := `SELECT ` + foodColumns + ` FROM foods WHERE barcode = $1`
+	return s.get(ctx, query, barcode)
+}
+
+func (s *FoodsStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]store.Food, error) {
+	query := `
+		SELECT ` + foodColumns + `
+		FROM foods
+		WHERE name LIKE '%' || $1 || '%'
+		ORDER BY name ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	return s.query(ctx, query, fq.Search, fq.Limit, fq.Offset)
+}
+
+// Search returns the foods whose name is similar to the search, best
+// matches first. Without a trigram index every name is compared, which is
+// fine for the size of a local database.
+func (s *FoodsStore) Search(ctx context.Context, fq pagination.PaginatedQuery) ([]store.Food, error) {
+	query := `
+		SELECT ` + foodColumns + `
+		FROM foods
+		WHERE similarity(name, $1) >= $4 OR name LIKE '%' || $1 || '%'
+		ORDER BY similarity(name, $1) DESC, name
+		LIMIT $2 OFFSET $3
+	`
+	return s.query(ctx, query, fq.Search, fq.Limit, fq.Offset, trigram.Threshold)
+}
+
+func (s *FoodsStore) get(ctx context.Context, query string, args ...any) (*store.Food, error) {
+	var f store.Food
+	if err := scanFood(s.db.QueryRowContext(ctx, query, args...), &f); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func (s *FoodsStore) query(ctx context.Context, query string, args ...any) ([]store.Food, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	foods := make([]store.Food, 0)
+	for rows.Next() {
+		var f store.Food
+		if err := scanFood(rows, &f); err != nil {
+			return nil, err
+		}
+		foods = append(foods, f)
+	}
+
+	return foods, rows.Err()
+}
+
+func scanFood(row interface{ Scan(...any) error }, f *store.Food) error {
+	return row.Scan(
+		&f.ID,
+		&f.UserID,
+		&f.Barcode,
+		&f.Name,
+		&f.Calories,
+		&f.Proteins,
+		&f.Fats,
+		&f.Carbohydrates,
+		&f.CreatedAt,
+	)
+}
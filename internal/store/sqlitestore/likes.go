@@ -0,0 +1,52 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type LikesStore struct {
+	db *sql.DB
+}
+
+func (s *LikesStore) CreateExercise(ctx context.Context, userID, exerciseID int64) error {
+	return s.like(ctx, `INSERT INTO exercise_likes (user_id, exercise_id) VALUES ($1, $2)`, userID, exerciseID)
+}
+
+func (s *LikesStore) DeleteExercise(ctx context.Context, userID, exerciseID int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM exercise_likes WHERE user_id = $1 AND exercise_id = $2`,
+		userID,
+		exerciseID,
+	)
+	return err
+}
+
+func (s *LikesStore) CreateWorkout(ctx context.Context, userID, workoutID int64) error {
+	return s.like(ctx, `INSERT INTO workout_likes (user_id, workout_id) VALUES ($1, $2)`, userID, workoutID)
+}
+
+func (s *LikesStore) DeleteWorkout(ctx context.Context, userID, workoutID int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM workout_likes WHERE user_id = $1 AND workout_id = $2`,
+		userID,
+		workoutID,
+	)
+	return err
+}
+
+func (s *LikesStore) like(ctx context.Context, stmt string, userID, id int64) error {
+	_, err := s.db.ExecContext(ctx, stmt, userID, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return fkViolationAsNotFound(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type MealsStore struct {
+	db *sql.DB
+}
+
+func (s *MealsStore) Create(ctx context.Context, m *store.Meal) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO meals (user_id, food_id, meal, grams, date)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`
+		err := tx.QueryRowContext(ctx, query, m.UserID, m.FoodID, m.Meal, m.Grams, date(m.Date)).Scan(&m.ID)
+		if err != nil {
+			return fkViolationAsNotFound(err)
+		}
+
+		query = `
+			SELECT name, calories * $2 / 100, proteins * $2 / 100, fats * $2 / 100, carbohydrates * $2 / 100
+			FROM foods WHERE id = $1
+		`
+		return tx.QueryRowContext(ctx, query, m.FoodID, m.Grams).Scan(
+			&m.FoodName,
+			&m.Calories,
+			&m.Proteins,
+			&m.Fats,
+			&m.Carbohydrates,
+		)
+	})
+}
+
+// GetByDate returns the meals the user ate on the date, in meal order.
+func (s *MealsStore) GetByDate(ctx context.Context, userID int64, day time.Time) ([]store.Meal, error) {
+	query := `
+		SELECT m.id, m.user_id, m.food_id, f.name, m.meal, m.grams, m.date,
+		  f.calories * m.grams / 100, f.proteins * m.grams / 100,
+		  f.fats * m.grams / 100, f.carbohydrates * m.grams / 100
+		FROM meals m
+		JOIN foods f ON f.id = m.food_id
+		WHERE m.user_id = $1 AND m.date = $2
+		ORDER BY CASE m.meal WHEN 'breakfast' THEN 1 WHEN 'lunch' THEN 2 WHEN 'dinner' THEN 3 ELSE 4 END,
+		  m.created_at, m.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, date(day))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	meals := make([]store.Meal, 0)
+	for rows.Next() {
+		var m store.Meal
+		err := rows.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.FoodID,
+			&m.FoodName,
+			&m.Meal,
+			&m.Grams,
+			&m.Date,
+			&m.Calories,
+			&m.Proteins,
+			&m.Fats,
+			&m.Carbohydrates,
+		)
+		if err != nil {
+			return nil, err
+		}
+		meals = append(meals, m)
+	}
+
+	return meals, rows.Err()
+}
+
+// GetDailyCalories returns the calories eaten per day from since until the
+// day before until, days without logged meals are left out.
+func (s *MealsStore) GetDailyCalories(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.DailyIntake, error) {
+	query := `
+		SELECT m.date, SUM(f.calories * m.grams / 100)
+		FROM meals m
+		JOIN foods f ON f.id = m.food_id
+		WHERE m.user_id = $1 AND m.date >= $2 AND m.date < $3
+		GROUP BY m.date
+		ORDER BY m.date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, date(since), date(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intake := make([]store.DailyIntake, 0)
+	for rows.Next() {
+		var di store.DailyIntake
+		if err := rows.Scan(&di.Date, &di.Calories); err != nil {
+			return nil, err
+		}
+		intake = append(intake, di)
+	}
+
+	return intake, rows.Err()
+}
+
+func (s *MealsStore) Delete(ctx context.Context, id, userID int64) error {
+	return execAffectingOne(ctx, s.db, `DELETE FROM meals WHERE id = $1 AND user_id = $2`, id, userID)
+}
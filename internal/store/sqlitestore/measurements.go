@@ -0,0 +1,117 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type MeasurementsStore struct {
+	db *sql.DB
+}
+
+func (s *MeasurementsStore) Add(ctx context.Context, m *store.Measurement) error {
+	query := `
+		INSERT INTO measurements (user_id, metric, date, value, unit) VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := s.db.ExecContext(ctx, query, m.UserID, m.Metric, date(m.Date), m.Value, m.Unit)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return fkViolationAsNotFound(err)
+	}
+
+	return nil
+}
+
+func (s *MeasurementsStore) Update(ctx context.Context, m *store.Measurement) error {
+	query := `
+		UPDATE measurements SET value = $1, unit = $2
+		WHERE user_id = $3 AND metric = $4 AND date = $5
+	`
+	return execAffectingOne(ctx, s.db, query, m.Value, m.Unit, m.UserID, m.Metric, date(m.Date))
+}
+
+// GetRange returns the measurements between since and until, oldest first.
+// An empty metric returns all metrics.
+func (s *MeasurementsStore) GetRange(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	since, until time.Time,
+) ([]store.Measurement, error) {
+	query := `
+		SELECT user_id, metric, date, value, unit FROM measurements
+		WHERE user_id = $1 AND ($2 = '' OR metric = $2) AND date >= $3 AND date <= $4
+		ORDER BY date, metric
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, metric, date(since), date(until))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMeasurements(rows)
+}
+
+// GetLatest returns the last measurement of every metric the user logged.
+func (s *MeasurementsStore) GetLatest(ctx context.Context, userID int64) ([]store.Measurement, error) {
+	query := `
+		SELECT m.user_id, m.metric, m.date, m.value, m.unit FROM measurements m
+		WHERE m.user_id = $1 AND m.date = (
+		  SELECT MAX(date) FROM measurements WHERE user_id = m.user_id AND metric = m.metric
+		)
+		ORDER BY m.metric
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMeasurements(rows)
+}
+
+// GetLatestAt returns the last measurement of the metric logged on or
+// before the date.
+func (s *MeasurementsStore) GetLatestAt(
+	ctx context.Context,
+	userID int64,
+	metric string,
+	at time.Time,
+) (*store.Measurement, error) {
+	query := `
+		SELECT user_id, metric, date, value, unit FROM measurements
+		WHERE user_id = $1 AND metric = $2 AND date <= $3
+		ORDER BY date DESC
+		LIMIT 1
+	`
+	m := &store.Measurement{}
+	err := s.db.QueryRowContext(ctx, query, userID, metric, date(at)).
+		Scan(&m.UserID, &m.Metric, &m.Date, &m.Value, &m.Unit)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func scanMeasurements(rows *sql.Rows) ([]store.Measurement, error) {
+	defer rows.Close()
+
+	measurements := make([]store.Measurement, 0)
+	for rows.Next() {
+		var m store.Measurement
+		if err := rows.Scan(&m.UserID, &m.Metric, &m.Date, &m.Value, &m.Unit); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m)
+	}
+
+	return measurements, rows.Err()
+}
@@ -0,0 +1,203 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type PersonalRecordsStore struct {
+	db *sql.DB
+}
+
+func (s *PersonalRecordsStore) GetByUserID(ctx context.Context, userID int64) ([]store.PersonalRecord, error) {
+	query := `
+		SELECT pr.user_id, pr.exercise_id, e.name, pr.kind, pr.value, pr.finished_workout_id, pr.achieved_at
+		FROM personal_records pr
+		JOIN exercises e ON e.id = pr.exercise_id
+		WHERE pr.user_id = $1
+		ORDER BY e.name, pr.kind
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]store.PersonalRecord, 0)
+	for rows.Next() {
+		var pr store.PersonalRecord
+		err := rows.Scan(
+			&pr.UserID,
+			&pr.ExerciseID,
+			&pr.ExerciseName,
+			&pr.Kind,
+			&pr.Value,
+			&pr.FinishedWorkoutID,
+			&pr.AchievedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, pr)
+	}
+
+	return records, rows.Err()
+}
+
+// GetProgress returns the user's sessions containing the exercise, one point
+// per finished workout, within the since and until bounds of the query.
+func (s *PersonalRecordsStore) GetProgress(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID, exerciseID int64,
+) ([]store.ExerciseProgress, error) {
+	since, err := parseBound(fq.Since)
+	if err != nil {
+		return nil, err
+	}
+	until, err := parseBound(fq.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT fw.id, fw.ended_at, COUNT(*), MAX(fs.weight),
+		  MAX(CASE WHEN fs.reps = 1 THEN fs.weight WHEN fs.reps > 1 THEN fs.weight * (1 + fs.reps / 30.0) ELSE 0 END),
+		  MAX(fs.reps), MAX(fs.duration), SUM(fs.weight * fs.reps)
+		FROM finished_workout_sets fs
+		JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		WHERE fw.user_id = $1 AND fs.exercise_id = $2 AND
+		  ($3 = '' OR fw.ended_at >= $3) AND
+		  ($4 = '' OR fw.ended_at <= $4)
+		GROUP BY fw.id
+		ORDER BY fw.ended_at ` + fq.Sort + `
+		LIMIT $5 OFFSET $6
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, exerciseID, since, until, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := make([]store.ExerciseProgress, 0)
+	for rows.Next() {
+		var p store.ExerciseProgress
+		err := rows.Scan(
+			&p.FinishedWorkoutID,
+			&p.Date,
+			&p.Sets,
+			&p.MaxWeight,
+			&p.Estimated1RM,
+			&p.MaxReps,
+			&p.MaxDuration,
+			&p.Volume,
+		)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, rows.Err()
+}
+
+// updateRecords compares the sets of the finished workout with the stored
+// records and saves the ones it beats. It returns the new records.
+func updateRecords(ctx context.Context, tx *sql.Tx, fn *store.FinishedWorkout) ([]store.PersonalRecord, error) {
+	if len(fn.Sets) == 0 {
+		return nil, nil
+	}
+
+	isDuration, err := exercisesIsDuration(ctx, tx, fn.Sets)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]store.PersonalRecord, 0)
+	for _, pr := range store.RecordCandidates(fn, isDuration) {
+		isNew, err := saveRecord(ctx, tx, &pr)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			records = append(records, pr)
+		}
+	}
+
+	return records, nil
+}
+
+func exercisesIsDuration(ctx context.Context, tx *sql.Tx, sets []store.WorkoutSet) (map[int64]bool, error) {
+	ids := make([]any, 0, len(sets))
+	for _, ws := range sets {
+		ids = append(ids, ws.ExerciseID)
+	}
+
+	query := `SELECT id, is_duration FROM exercises WHERE id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+	rows, err := tx.QueryContext(ctx, query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	isDuration := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		var d bool
+		if err := rows.Scan(&id, &d); err != nil {
+			return nil, err
+		}
+		isDuration[id] = d
+	}
+
+	return isDuration, rows.Err()
+}
+
+// saveRecord stores the record when it beats the current one and reports
+// whether it did, filling Previous with the beaten value. The transaction
+// holds the database write lock, so the record cannot change in between.
+func saveRecord(ctx context.Context, tx *sql.Tx, pr *store.PersonalRecord) (bool, error) {
+	query := `
+		SELECT value FROM personal_records
+		WHERE user_id = $1 AND exercise_id = $2 AND kind = $3
+	`
+	var previous float32
+	err := tx.QueryRowContext(ctx, query, pr.UserID, pr.ExerciseID, pr.Kind).Scan(&previous)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return false, err
+	case previous >= pr.Value:
+		return false, nil
+	default:
+		pr.Previous = &previous
+	}
+
+	query = `
+		INSERT INTO personal_records (user_id, exercise_id, kind, value, finished_workout_id, achieved_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, exercise_id, kind) DO UPDATE
+		SET value = excluded.value, finished_workout_id = excluded.finished_workout_id,
+		  achieved_at = excluded.achieved_at
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		pr.UserID,
+		pr.ExerciseID,
+		pr.Kind,
+		pr.Value,
+		pr.FinishedWorkoutID,
+		timestamp(pr.AchievedAt),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
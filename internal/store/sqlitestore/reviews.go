@@ -0,0 +1,97 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type ReviewsStore struct {
+	db *sql.DB
+}
+
+func (s *ReviewsStore) CreateWorkout(ctx context.Context, wr *store.WorkoutReview) error {
+	query := `
+		INSERT INTO workout_reviews (user_id, workout_id, rating, title, content) VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, wr.UserID, wr.WorkoutID, wr.Rating, wr.Title, wr.Content).
+		Scan(&wr.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return fkViolationAsNotFound(err)
+	}
+
+	return nil
+}
+
+func (s *ReviewsStore) Update(ctx context.Context, wr *store.WorkoutReview) error {
+	query := `
+		UPDATE workout_reviews SET rating = $1, title = $2, content = $3
+		WHERE user_id = $4 AND workout_id = $5
+		RETURNING created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, wr.Rating, wr.Title, wr.Content, wr.UserID, wr.WorkoutID).
+		Scan(&wr.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *ReviewsStore) Delete(ctx context.Context, userID, workoutID int64) error {
+	query := `
+		DELETE FROM workout_reviews WHERE user_id = $1 AND workout_id = $2
+	`
+	return execAffectingOne(ctx, s.db, query, userID, workoutID)
+}
+
+func (s *ReviewsStore) Get(ctx context.Context, workoutID int64) ([]store.WorkoutReviewWithMetadata, error) {
+	query := `
+		SELECT wr.user_id, wr.workout_id, wr.rating, wr.title, wr.content, wr.created_at, u.username
+		FROM workout_reviews wr
+		LEFT JOIN users u ON u.id = wr.user_id
+		WHERE wr.workout_id = $1 AND NOT wr.is_hidden
+		ORDER BY wr.rowid
+	`
+	rows, err := s.db.QueryContext(ctx, query, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workoutReviews []store.WorkoutReviewWithMetadata
+	for rows.Next() {
+		var wr store.WorkoutReviewWithMetadata
+		err := rows.Scan(
+			&wr.UserID,
+			&wr.WorkoutID,
+			&wr.Rating,
+			&wr.Title,
+			&wr.Content,
+			&wr.CreatedAt,
+			&wr.Username,
+		)
+		if err != nil {
+			return nil, err
+		}
+		workoutReviews = append(workoutReviews, wr)
+	}
+
+	return workoutReviews, rows.Err()
+}
+
+func (s *ReviewsStore) SetHidden(ctx context.Context, userID, workoutID int64, hidden bool) error {
+	query := `
+		UPDATE workout_reviews SET is_hidden = $1 WHERE user_id = $2 AND workout_id = $3
+	`
+	return execAffectingOne(ctx, s.db, query, hidden, userID, workoutID)
+}
@@ -0,0 +1,208 @@
+// Package sqlitestore implements store.Storage on top of SQLite, so the API
+// can run from a single binary without a Postgres server. The schema is
+// created by the SQLite migrations of the db package.
+//
+// The queries follow the Postgres store with the differences of the
+// dialect: the muscles arrays are stored as JSON arrays and filtered with
+// json_each, ILIKE is replaced by LIKE, which ignores the case of ASCII
+// letters, and the pg_trgm similarity is registered as a SQL function.
+package sqlitestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/trigram"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(
+		"similarity",
+		2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			a, _ := args[0].(string)
+			b, _ := args[1].(string)
+			return trigram.Similarity(a, b), nil
+		},
+	)
+}
+
+// New returns the storage of the SQLite database, which must be opened with
+// foreign keys enabled.
+func New(db *sql.DB) store.Storage {
+	return store.Storage{
+		Users:            &UserStore{db},
+		Exercises:        &ExerciseStore{db},
+		Likes:            &LikesStore{db},
+		Workouts:         &WorkoutStore{db},
+		Reviews:          &ReviewsStore{db},
+		FinishedWorkouts: &FinishedWorkoutsStore{db},
+		ActiveWorkouts:   &ActiveWorkoutsStore{db},
+		PersonalRecords:  &PersonalRecordsStore{db},
+		Measurements:     &MeasurementsStore{db},
+		Foods:            &FoodsStore{db},
+		Meals:            &MealsStore{db},
+		Stats:            &StatsStore{db},
+		Sessions:         &SessionsStore{db},
+	}
+}
+
+func withTx(db *sql.DB, ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execAffectingOne executes the statement and returns store.ErrNotFound when
+// it did not affect any row.
+func execAffectingOne(ctx context.Context, db execer, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err is a unique or primary key
+// violation, SQLite reports them with different codes.
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func isFKViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+// fkViolationAsNotFound maps foreign key violations, which happen when a
+// referenced row does not exist, to store.ErrNotFound.
+func fkViolationAsNotFound(err error) error {
+	if isFKViolation(err) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// timestamp formats t like the TIMESTAMP columns, which are compared as
+// text, in UTC with the precision of a second. It truncates rather than
+// rounds, a password_changed_at rounded up would revoke the tokens issued
+// in the same second.
+func timestamp(t time.Time) string {
+	return t.UTC().Truncate(time.Second).Format(time.DateTime)
+}
+
+// date formats the date of t like the DATE columns. As with a Postgres date
+// parameter, the date is taken in the location of t.
+func date(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+func nullDate(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return date(*t)
+}
+
+// parseDate parses a date computed by a query, only plain columns declared
+// as DATE are read back as time.Time by the driver.
+func parseDate(s string) (time.Time, error) {
+	return time.Parse(time.DateOnly, s)
+}
+
+// parseBound converts the since and until strings of a paginated query to
+// the TIMESTAMP format, they are read in the local time zone like Postgres
+// does with the server time zone. An empty string stays empty.
+func parseBound(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	t, err := time.ParseInLocation(time.DateTime, s, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q: %w", s, err)
+	}
+	return timestamp(t), nil
+}
+
+func hashToken(plainToken string) string {
+	hash := sha256.Sum256([]byte(plainToken))
+	return hex.EncodeToString(hash[:])
+}
+
+// stringArray stores a []string in a TEXT column as a JSON array, the way
+// pq.Array maps it to a Postgres array.
+type stringArray struct {
+	a *[]string
+}
+
+func array(a *[]string) stringArray {
+	return stringArray{a}
+}
+
+func (s stringArray) Value() (driver.Value, error) {
+	if *s.a == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(*s.a)
+	return string(b), err
+}
+
+func (s stringArray) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), s.a)
+	case []byte:
+		return json.Unmarshal(v, s.a)
+	case nil:
+		*s.a = nil
+		return nil
+	default:
+		return fmt.Errorf("sqlitestore: cannot scan %T into a string array", src)
+	}
+}
+
+// containsMuscles is the condition of a query where the muscles JSON array
+// column contains all the values of the JSON array parameter, like the @>
+// operator of Postgres arrays. An empty parameter matches every row.
+func containsMuscles(column, param string) string {
+	return `NOT EXISTS (
+		  SELECT 1 FROM json_each(` + param + `) t
+		  WHERE t.value NOT IN (SELECT value FROM json_each(` + column + `))
+		)`
+}
@@ -0,0 +1,142 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type StatsStore struct {
+	db *sql.DB
+}
+
+func (s *StatsStore) Get(ctx context.Context, userID int64, since, until time.Time) (*store.Stats, error) {
+	st := &store.Stats{Since: since, Until: until}
+
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(duration), 0)
+		FROM finished_workouts
+		WHERE user_id = $1 AND ended_at >= $2 AND ended_at <= $3
+	`
+	err := s.db.QueryRowContext(ctx, query, userID, timestamp(since), timestamp(until)).
+		Scan(&st.Workouts, &st.TotalDuration)
+	if err != nil {
+		return nil, err
+	}
+	if weeks := until.Sub(since).Hours() / (24 * 7); weeks > 0 {
+		st.WorkoutsPerWeek = float64(st.Workouts) / weeks
+	}
+
+	days, err := s.getTrainingDays(ctx, userID, until)
+	if err != nil {
+		return nil, err
+	}
+	st.CurrentStreak, st.LongestStreak = store.Streaks(days, since, until)
+
+	if st.Muscles, err = s.getMuscleVolume(ctx, userID, since, until); err != nil {
+		return nil, err
+	}
+
+	if st.Weight, err = s.getWeight(ctx, userID, since, until); err != nil {
+		return nil, err
+	}
+	if n := len(st.Weight); n > 1 {
+		st.WeightChange = st.Weight[n-1].Weight - st.Weight[0].Weight
+	}
+
+	return st, nil
+}
+
+// getTrainingDays returns the days with at least one finished workout up to
+// until, newest first. The timestamps are stored in UTC, so are the days.
+func (s *StatsStore) getTrainingDays(ctx context.Context, userID int64, until time.Time) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT date(ended_at) AS day
+		FROM finished_workouts
+		WHERE user_id = $1 AND ended_at <= $2
+		ORDER BY day DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, timestamp(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]time.Time, 0)
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		d, err := parseDate(day)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+func (s *StatsStore) getMuscleVolume(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.MuscleVolume, error) {
+	query := `
+		SELECT m.value, COUNT(*), COALESCE(SUM(fs.weight * fs.reps), 0), COALESCE(SUM(fs.duration), 0)
+		FROM finished_workout_sets fs
+		JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		JOIN exercises e ON e.id = fs.exercise_id
+		JOIN json_each(e.muscles) m
+		WHERE fw.user_id = $1 AND fw.ended_at >= $2 AND fw.ended_at <= $3
+		GROUP BY m.value
+		ORDER BY COUNT(*) DESC, m.value
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, timestamp(since), timestamp(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var total int
+	muscles := make([]store.MuscleVolume, 0)
+	for rows.Next() {
+		var mv store.MuscleVolume
+		if err := rows.Scan(&mv.Muscle, &mv.Sets, &mv.Volume, &mv.Duration); err != nil {
+			return nil, err
+		}
+		total += mv.Sets
+		muscles = append(muscles, mv)
+	}
+	for i := range muscles {
+		muscles[i].Share = float32(muscles[i].Sets) / float32(total)
+	}
+
+	return muscles, rows.Err()
+}
+
+func (s *StatsStore) getWeight(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.UserWeightByDate, error) {
+	query := `
+		SELECT date, weight FROM user_weight
+		WHERE user_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, date(since), date(until))
+	if err != nil {
+		return nil, err
+	}
+
+	weight := make([]store.UserWeightByDate, 0)
+	if err := scanWeight(rows, func(uw store.UserWeightByDate) { weight = append(weight, uw) }); err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
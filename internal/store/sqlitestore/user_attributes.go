@@ -0,0 +1,245 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+// userAttrColumns are the user_attributes columns read by scanUserAttr,
+// without the weight.
+const userAttrColumns = `ua.user_id, ua.is_male, ua.height, ua.goal, ua.weight_goal, ua.birth_date,
+	ua.activity_level, ua.formula, ua.body_fat, ua.protein_ratio, ua.fat_ratio, ua.carb_ratio`
+
+func scanUserAttr(row interface{ Scan(...any) error }, ua *store.UserAttributes, dest ...any) error {
+	var proteins, fats, carbs sql.NullFloat64
+	err := row.Scan(append([]any{
+		&ua.UserID,
+		&ua.IsMale,
+		&ua.Height,
+		&ua.Goal,
+		&ua.WeightGoal,
+		&ua.BirthDate,
+		&ua.ActivityLevel,
+		&ua.Formula,
+		&ua.BodyFat,
+		&proteins,
+		&fats,
+		&carbs,
+	}, dest...)...)
+	if err != nil {
+		return err
+	}
+
+	ua.Age = store.AgeAt(ua.BirthDate, time.Now())
+	if proteins.Valid && fats.Valid && carbs.Valid {
+		ua.MacroRatios = &store.MacroRatios{
+			Proteins:      float32(proteins.Float64),
+			Fats:          float32(fats.Float64),
+			Carbohydrates: float32(carbs.Float64),
+		}
+	}
+	return nil
+}
+
+func (s *UserStore) AddUserWeight(ctx context.Context, userID int64, weight float32) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		return s.addUserWeight(ctx, tx, userID, weight)
+	})
+}
+
+func (s *UserStore) UpdateUserWeight(ctx context.Context, userID int64, weight float32) error {
+	query := `
+		UPDATE user_weight SET weight = $1 WHERE user_id = $2 AND date = $3
+	`
+	_, err := s.db.ExecContext(ctx, query, weight, userID, date(time.Now()))
+	return err
+}
+
+// GetUserAttr returns the attributes with the last logged weight.
+func (s *UserStore) GetUserAttr(ctx context.Context, userID int64) (*store.UserAttributes, error) {
+	query := `
+		SELECT ` + userAttrColumns + `, uw.weight
+		FROM user_attributes ua
+		JOIN user_weight uw ON ua.user_id = uw.user_id
+		WHERE ua.user_id = $1
+		ORDER BY uw.date DESC
+		LIMIT 1
+	`
+	userAttr := &store.UserAttributes{}
+	err := scanUserAttr(s.db.QueryRowContext(ctx, query, userID), userAttr, &userAttr.Weight)
+	if err != nil {
+		return nil, err
+	}
+
+	return userAttr, nil
+}
+
+// GetUserAttrAt returns the attributes with the weight that was current on
+// the date, falling back to the first logged weight for earlier dates.
+func (s *UserStore) GetUserAttrAt(
+	ctx context.Context,
+	userID int64,
+	at time.Time,
+) (*store.UserAttributes, error) {
+	query := `
+		SELECT ` + userAttrColumns + `, uw.weight
+		FROM user_attributes ua
+		JOIN user_weight uw ON ua.user_id = uw.user_id
+		WHERE ua.user_id = $1
+		ORDER BY uw.date <= $2 DESC,
+		  CASE WHEN uw.date <= $2 THEN uw.date END DESC,
+		  uw.date
+		LIMIT 1
+	`
+	userAttr := &store.UserAttributes{}
+	err := scanUserAttr(s.db.QueryRowContext(ctx, query, userID, date(at)), userAttr, &userAttr.Weight)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+	userAttr.Age = store.AgeAt(userAttr.BirthDate, at)
+
+	return userAttr, nil
+}
+
+// UpdateUserAttr saves the attributes except the weight, which is logged
+// separately.
+func (s *UserStore) UpdateUserAttr(ctx context.Context, ua *store.UserAttributes) error {
+	return updateUserAttr(ctx, s.db, ua)
+}
+
+func updateUserAttr(ctx context.Context, db execer, ua *store.UserAttributes) error {
+	query := `
+		UPDATE user_attributes
+		SET is_male = $2, height = $3, goal = $4, weight_goal = $5, birth_date = $6, activity_level = $7,
+		  formula = $8, body_fat = $9, protein_ratio = $10, fat_ratio = $11, carb_ratio = $12
+		WHERE user_id = $1
+	`
+	var proteins, fats, carbs *float32
+	if ua.MacroRatios != nil {
+		proteins, fats, carbs = &ua.MacroRatios.Proteins, &ua.MacroRatios.Fats, &ua.MacroRatios.Carbohydrates
+	}
+
+	return execAffectingOne(
+		ctx,
+		db,
+		query,
+		ua.UserID,
+		ua.IsMale,
+		ua.Height,
+		ua.Goal,
+		ua.WeightGoal,
+		nullDate(ua.BirthDate),
+		ua.ActivityLevel,
+		ua.Formula,
+		ua.BodyFat,
+		proteins,
+		fats,
+		carbs,
+	)
+}
+
+func (s *UserStore) GetUserWeight(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.UserWeightByDate, error) {
+	query := `
+		SELECT date, weight FROM user_weight
+		WHERE user_id = $1
+		ORDER BY date
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var weight []store.UserWeightByDate
+	if err := scanWeight(rows, func(uw store.UserWeightByDate) { weight = append(weight, uw) }); err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
+
+// GetUserWeightRange returns the weight logged from since until the day
+// before until, oldest first.
+func (s *UserStore) GetUserWeightRange(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]store.UserWeightByDate, error) {
+	query := `
+		SELECT date, weight FROM user_weight
+		WHERE user_id = $1 AND date >= $2 AND date < $3
+		ORDER BY date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, date(since), date(until))
+	if err != nil {
+		return nil, err
+	}
+
+	weight := make([]store.UserWeightByDate, 0)
+	if err := scanWeight(rows, func(uw store.UserWeightByDate) { weight = append(weight, uw) }); err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
+
+func scanWeight(rows *sql.Rows, add func(store.UserWeightByDate)) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		var uw store.UserWeightByDate
+		if err := rows.Scan(&uw.Date, &uw.Weight); err != nil {
+			return err
+		}
+		add(uw)
+	}
+
+	return rows.Err()
+}
+
+func (s *UserStore) addUserWeight(ctx context.Context, tx *sql.Tx, userID int64, weight float32) error {
+	query := `
+		INSERT INTO user_weight (user_id, date, weight) VALUES ($1, $2, $3)
+	`
+	_, err := tx.ExecContext(ctx, query, userID, date(time.Now()), weight)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.ErrConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) addUserAttr(ctx context.Context, tx *sql.Tx, ua store.UserAttributes) error {
+	query := `
+		INSERT INTO user_attributes (user_id, is_male, height, goal, weight_goal, birth_date, activity_level, formula)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'moderate'), COALESCE(NULLIF($8, ''), 'mifflin'))
+	`
+	_, err := tx.ExecContext(
+		ctx,
+		query,
+		ua.UserID,
+		ua.IsMale,
+		ua.Height,
+		ua.Goal,
+		ua.WeightGoal,
+		nullDate(ua.BirthDate),
+		ua.ActivityLevel,
+		ua.Formula,
+	)
+	return err
+}
@@ -0,0 +1,351 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type UserStore struct {
+	db *sql.DB
+}
+
+const userColumns = `id, email, username, password, created_at, is_active, role, password_changed_at, units`
+
+func (s *UserStore) CreateAndInvite(
+	ctx context.Context,
+	user *store.User,
+	token string,
+	exp time.Duration,
+) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.create(ctx, tx, user); err != nil {
+			return err
+		}
+
+		if err := s.createInvite(ctx, tx, user.ID, token, exp); err != nil {
+			return err
+		}
+
+		user.UserAttr.UserID = user.ID
+		if err := s.addUserAttr(ctx, tx, user.UserAttr); err != nil {
+			return err
+		}
+
+		return s.addUserWeight(ctx, tx, user.ID, user.UserAttr.Weight)
+	})
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*store.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return s.get(ctx, query, email)
+}
+
+func (s *UserStore) GetByID(ctx context.Context, id int64) (*store.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return s.get(ctx, query, id)
+}
+
+func (s *UserStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]store.User, error) {
+	query := `
+		SELECT id, email, username, created_at, is_active, role, units FROM users
+		WHERE username LIKE '%' || $1 || '%' OR email LIKE '%' || $1 || '%'
+		ORDER BY id ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, fq.Search, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]store.User, 0)
+	for rows.Next() {
+		var u store.User
+		err := rows.Scan(&u.ID, &u.Email, &u.Username, &u.CreatedAt, &u.IsActive, &u.Role, &u.Units)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, rows.Err()
+}
+
+// Deactivate marks the user as inactive and revokes all of its sessions.
+func (s *UserStore) Deactivate(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET is_active = FALSE WHERE id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.ErrNotFound
+		}
+
+		return revokeUserSessions(ctx, tx, userID)
+	})
+}
+
+func (s *UserStore) SetRole(ctx context.Context, userID int64, role string) error {
+	return execAffectingOne(ctx, s.db, `UPDATE users SET role = $1 WHERE id = $2`, role, userID)
+}
+
+func (s *UserStore) SetUnits(ctx context.Context, userID int64, units string) error {
+	return execAffectingOne(ctx, s.db, `UPDATE users SET units = $1 WHERE id = $2`, units, userID)
+}
+
+func (s *UserStore) Activate(ctx context.Context, plainToken string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT user_id FROM invitation
+			WHERE token = $1 AND exp > $2
+		`
+		var userID int64
+		err := tx.QueryRowContext(ctx, query, hashToken(plainToken), timestamp(time.Now())).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = TRUE WHERE id = $1`, userID); err != nil {
+			return err
+		}
+
+		return s.deleteInvitations(ctx, tx, userID)
+	})
+}
+
+// Reinvite replaces the pending invitations of the user with a new one.
+func (s *UserStore) Reinvite(ctx context.Context, userID int64, token string, exp time.Duration) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.deleteInvitations(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.createInvite(ctx, tx, userID, token, exp)
+	})
+}
+
+// CreatePasswordReset replaces the pending password resets of the user with a new one.
+func (s *UserStore) CreatePasswordReset(
+	ctx context.Context,
+	userID int64,
+	token string,
+	exp time.Duration,
+) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.deletePasswordResets(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO password_resets (token, user_id, exp)
+			VALUES ($1, $2, $3)
+		`
+		_, err := tx.ExecContext(ctx, query, token, userID, timestamp(time.Now().Add(exp)))
+		return fkViolationAsNotFound(err)
+	})
+}
+
+// ResetPassword sets the new password hash for the user of the plain reset
+// token and revokes all sessions of the user. The token can be used only once.
+func (s *UserStore) ResetPassword(ctx context.Context, plainToken string, hash []byte) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT user_id FROM password_resets
+			WHERE token = $1 AND exp > $2
+		`
+		var userID int64
+		err := tx.QueryRowContext(ctx, query, hashToken(plainToken), timestamp(time.Now())).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+
+		if err := s.updatePassword(ctx, tx, userID, hash); err != nil {
+			return err
+		}
+
+		if err := revokeUserSessions(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.deletePasswordResets(ctx, tx, userID)
+	})
+}
+
+// UpdateProfile applies the profile changes of the user in one transaction,
+// the email is changed once the token sent to the new address is confirmed.
+func (s *UserStore) UpdateProfile(ctx context.Context, userID int64, p store.ProfileUpdate) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if p.Username != nil {
+			query := `UPDATE users SET username = $1 WHERE id = $2`
+			if err := execAffectingOne(ctx, tx, query, *p.Username, userID); err != nil {
+				return duplicateUserError(err)
+			}
+		}
+
+		if p.Units != nil {
+			query := `UPDATE users SET units = $1 WHERE id = $2`
+			if err := execAffectingOne(ctx, tx, query, *p.Units, userID); err != nil {
+				return err
+			}
+		}
+
+		if p.UserAttr != nil {
+			if err := updateUserAttr(ctx, tx, p.UserAttr); err != nil {
+				return err
+			}
+		}
+
+		if p.PasswordHash != nil {
+			if err := s.updatePassword(ctx, tx, userID, p.PasswordHash); err != nil {
+				return err
+			}
+			if err := revokeUserSessions(ctx, tx, userID); err != nil {
+				return err
+			}
+		}
+
+		if p.EmailChange != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM email_changes WHERE user_id = $1`, userID); err != nil {
+				return err
+			}
+
+			query := `
+				INSERT INTO email_changes (token, user_id, email, exp)
+				VALUES ($1, $2, $3, $4)
+			`
+			ec := p.EmailChange
+			_, err := tx.ExecContext(ctx, query, ec.Token, userID, ec.Email, timestamp(time.Now().Add(ec.Exp)))
+			if err != nil {
+				return fkViolationAsNotFound(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ConfirmEmailChange sets the pending email of the plain token as the user
+// email. The token can be used only once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, plainToken string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM email_changes
+			WHERE token = $1 AND exp > $2
+			RETURNING user_id, email
+		`
+		var userID int64
+		var email string
+		err := tx.QueryRowContext(ctx, query, hashToken(plainToken), timestamp(time.Now())).Scan(&userID, &email)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return store.ErrNotFound
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET email = $1 WHERE id = $2`, email, userID)
+		return duplicateUserError(err)
+	})
+}
+
+func (s *UserStore) get(ctx context.Context, query string, args ...any) (*store.User, error) {
+	u := &store.User{}
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&u.ID,
+		&u.Email,
+		&u.Username,
+		&u.Password.Hash,
+		&u.CreatedAt,
+		&u.IsActive,
+		&u.Role,
+		&u.PasswordChangedAt,
+		&u.Units,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (s *UserStore) create(ctx context.Context, tx *sql.Tx, u *store.User) error {
+	query := `
+		INSERT INTO users (email, username, password, units)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'metric'))
+		RETURNING id, created_at, units
+	`
+	err := tx.QueryRowContext(ctx, query, u.Email, u.Username, u.Password.Hash, u.Units).
+		Scan(&u.ID, &u.CreatedAt, &u.Units)
+	return duplicateUserError(err)
+}
+
+func (s *UserStore) updatePassword(ctx context.Context, tx *sql.Tx, userID int64, hash []byte) error {
+	query := `
+		UPDATE users SET password = $1, password_changed_at = $2 WHERE id = $3
+	`
+	_, err := tx.ExecContext(ctx, query, hash, timestamp(time.Now()), userID)
+	return err
+}
+
+func (s *UserStore) deletePasswordResets(ctx context.Context, tx *sql.Tx, userID int64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID)
+	return err
+}
+
+func (s *UserStore) createInvite(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID int64,
+	token string,
+	exp time.Duration,
+) error {
+	query := `
+		INSERT INTO invitation (token, user_id, exp)
+		VALUES ($1, $2, $3)
+	`
+	_, err := tx.ExecContext(ctx, query, token, userID, timestamp(time.Now().Add(exp)))
+	return err
+}
+
+func (s *UserStore) deleteInvitations(ctx context.Context, tx *sql.Tx, userID int64) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM invitation WHERE user_id = $1`, userID)
+	return err
+}
+
+// duplicateUserError maps the unique violations of the users table to the
+// store errors.
+func duplicateUserError(err error) error {
+	if !isUniqueViolation(err) {
+		return err
+	}
+
+	switch {
+	case strings.Contains(err.Error(), "users.email"):
+		return store.ErrDuplicateEmail
+	case strings.Contains(err.Error(), "users.username"):
+		return store.ErrDuplicateUsername
+	default:
+		return err
+	}
+}
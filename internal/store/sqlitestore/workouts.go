@@ -0,0 +1,300 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
+	"github.com/stanislavCasciuc/atom-fit/internal/store"
+)
+
+type WorkoutStore struct {
+	db *sql.DB
+}
+
+func (s *WorkoutStore) GetAll(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Workout, int, error) {
+	query := `
+		SELECT w.id, w.user_id, w.name, w.description, w.tutorial_link, w.created_at,
+		  COUNT(DISTINCT wl.user_id) AS likes,
+		  COUNT(DISTINCT wr.user_id) AS reviews_count,
+		  COALESCE(AVG(wr.rating), 0.0) AS average_rating,
+		  EXISTS (
+		    SELECT 1 FROM workout_likes wl2 WHERE wl2.workout_id = w.id AND wl2.user_id = $5
+		  ) AS user_liked,
+		  COUNT(*) OVER() AS total_count
+		FROM workouts w
+		LEFT JOIN workout_exercises we ON we.workout_id = w.id
+		LEFT JOIN exercises e ON we.exercise_id = e.id
+		LEFT JOIN workout_likes wl ON w.id = wl.workout_id
+		LEFT JOIN workout_reviews wr ON w.id = wr.workout_id AND NOT wr.is_hidden
+		WHERE (e.name LIKE '%' || $1 || '%' OR e.description LIKE '%' || $1 || '%' OR
+		    w.name LIKE '%' || $1 || '%' OR w.description LIKE '%' || $1 || '%') AND
+		  ` + containsMuscles("e.muscles", "$2") + ` AND NOT w.is_hidden
+		GROUP BY w.id
+		ORDER BY likes ` + fq.Sort + `
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, fq.Search, array(&fq.Tags), fq.Limit, fq.Offset, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	workouts := make([]store.Workout, 0)
+	for rows.Next() {
+		var w store.Workout
+		err := rows.Scan(
+			&w.ID,
+			&w.UserID,
+			&w.Name,
+			&w.Description,
+			&w.TutorialLink,
+			&w.CreatedAt,
+			&w.Likes,
+			&w.ReviewsCount,
+			&w.Rating,
+			&w.UserLiked,
+			&totalCount,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, totalCount, rows.Err()
+}
+
+func (s *WorkoutStore) GetUsersWorkouts(
+	ctx context.Context,
+	fq pagination.PaginatedQuery,
+	userID int64,
+) ([]store.Workout, error) {
+	query := `
+		SELECT w.id, w.user_id, w.name, w.description, w.tutorial_link, w.created_at,
+		  COUNT(DISTINCT wl.user_id) AS likes,
+		  COUNT(DISTINCT wr.user_id) AS reviews_count,
+		  COALESCE(AVG(wr.rating), 0.0) AS average_rating,
+		  EXISTS (
+		    SELECT 1 FROM workout_likes wl2 WHERE wl2.workout_id = w.id AND wl2.user_id = $1
+		  ) AS user_liked
+		FROM workouts w
+		LEFT JOIN workout_likes wl ON w.id = wl.workout_id
+		LEFT JOIN workout_reviews wr ON w.id = wr.workout_id AND NOT wr.is_hidden
+		WHERE w.user_id = $1 AND NOT w.is_hidden
+		GROUP BY w.id
+		ORDER BY likes ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := make([]store.Workout, 0)
+	for rows.Next() {
+		var w store.Workout
+		err := rows.Scan(
+			&w.ID,
+			&w.UserID,
+			&w.Name,
+			&w.Description,
+			&w.TutorialLink,
+			&w.CreatedAt,
+			&w.Likes,
+			&w.ReviewsCount,
+			&w.Rating,
+			&w.UserLiked,
+		)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
+}
+
+func (s *WorkoutStore) Create(ctx context.Context, w *store.Workout) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			INSERT INTO workouts (user_id, name, description, tutorial_link) VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at
+		`
+		err := tx.QueryRowContext(ctx, query, w.UserID, w.Name, w.Description, w.TutorialLink).
+			Scan(&w.ID, &w.CreatedAt)
+		if err != nil {
+			return fkViolationAsNotFound(err)
+		}
+
+		return s.addExercises(ctx, tx, w)
+	})
+}
+
+// Update changes the workout details and, when WorkoutExercises is not nil,
+// replaces the workout exercises with the given ones in their slice order.
+func (s *WorkoutStore) Update(ctx context.Context, w *store.Workout) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE workouts SET name = $1, description = $2, tutorial_link = $3 WHERE id = $4
+		`
+		res, err := tx.ExecContext(ctx, query, w.Name, w.Description, w.TutorialLink, w.ID)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.ErrNotFound
+		}
+
+		if w.WorkoutExercises == nil {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM workout_exercises WHERE workout_id = $1`, w.ID); err != nil {
+			return err
+		}
+
+		return s.addExercises(ctx, tx, w)
+	})
+}
+
+// Delete removes the workout together with its exercises, likes, reviews
+// and finished workouts.
+func (s *WorkoutStore) Delete(ctx context.Context, id int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		stmts := []string{
+			`DELETE FROM workout_likes WHERE workout_id = $1`,
+			`DELETE FROM workout_reviews WHERE workout_id = $1`,
+			`DELETE FROM finished_workouts WHERE workout_id = $1`,
+			`DELETE FROM workout_exercises WHERE workout_id = $1`,
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM workouts WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return store.ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+func (s *WorkoutStore) GetByID(ctx context.Context, id int64) (*store.Workout, error) {
+	query := `
+		SELECT w.id, w.user_id, w.name, w.description, w.tutorial_link, w.created_at,
+		  (SELECT COUNT(*) FROM workout_likes wl WHERE wl.workout_id = w.id) AS likes,
+		  (SELECT COUNT(*) FROM workout_reviews wr WHERE wr.workout_id = w.id AND NOT wr.is_hidden) AS reviews_count,
+		  (SELECT COALESCE(AVG(wr.rating), 0.0) FROM workout_reviews wr WHERE wr.workout_id = w.id AND NOT wr.is_hidden) AS average_rating,
+		  w.is_hidden
+		FROM workouts w
+		WHERE w.id = $1
+	`
+	w := &store.Workout{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&w.ID,
+		&w.UserID,
+		&w.Name,
+		&w.Description,
+		&w.TutorialLink,
+		&w.CreatedAt,
+		&w.Likes,
+		&w.ReviewsCount,
+		&w.Rating,
+		&w.IsHidden,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (s *WorkoutStore) GetWorkoutExercises(ctx context.Context, workoutID int64) ([]store.WorkoutExercises, error) {
+	query := `
+		SELECT e.id, e.user_id, e.name, e.description, e.is_duration, e.duration, e.tutorial_link,
+		  e.created_at, e.muscles, we.duration, we.position
+		FROM exercises e
+		JOIN workout_exercises we ON e.id = we.exercise_id
+		WHERE we.workout_id = $1
+		ORDER BY we.position
+	`
+	rows, err := s.db.QueryContext(ctx, query, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workoutExercises := make([]store.WorkoutExercises, 0)
+	for rows.Next() {
+		we := store.WorkoutExercises{WorkoutID: workoutID}
+		e := &we.Exercise
+		err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Name,
+			&e.Description,
+			&e.IsDuration,
+			&e.Duration,
+			&e.TutorialLink,
+			&e.CreatedAt,
+			array(&e.Muscles),
+			&we.Duration,
+			&we.Position,
+		)
+		if err != nil {
+			return nil, err
+		}
+		we.ExerciseID = e.ID
+		workoutExercises = append(workoutExercises, we)
+	}
+
+	return workoutExercises, rows.Err()
+}
+
+func (s *WorkoutStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	return execAffectingOne(ctx, s.db, `UPDATE workouts SET is_hidden = $1 WHERE id = $2`, hidden, id)
+}
+
+// addExercises adds the workout exercises positioned in slice order, an
+// exercise given twice is only added once.
+func (s *WorkoutStore) addExercises(ctx context.Context, tx *sql.Tx, w *store.Workout) error {
+	query := `
+		INSERT INTO workout_exercises (exercise_id, workout_id, duration, position) VALUES ($1, $2, $3, $4)
+	`
+	w.WorkoutExercises = store.UniqueExercises(w.WorkoutExercises)
+	for i := range w.WorkoutExercises {
+		we := &w.WorkoutExercises[i]
+		we.WorkoutID = w.ID
+		_, err := tx.ExecContext(ctx, query, we.ExerciseID, w.ID, we.Duration, we.Position)
+		if err != nil {
+			return fkViolationAsNotFound(err)
+		}
+	}
+
+	return nil
+}
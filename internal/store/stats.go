@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Stats sums up the training of a user between Since and Until. Durations
+// are in seconds and streaks are counted in consecutive training days.
+type Stats struct {
+	Since           time.Time          `json:"since"`
+	Until           time.Time          `json:"until"`
+	Workouts        int                `json:"workouts"`
+	WorkoutsPerWeek float64            `json:"workouts_per_week"`
+	TotalDuration   int                `json:"total_duration"`
+	CurrentStreak   int                `json:"current_streak"`
+	LongestStreak   int                `json:"longest_streak"`
+	Muscles         []MuscleVolume     `json:"muscles"`
+	Weight          []UserWeightByDate `json:"weight"`
+	WeightChange    float32            `json:"weight_change"`
+}
+
+// MuscleVolume is the work done on a muscle group, Volume is the sum of
+// weight times reps of the sets.
+type MuscleVolume struct {
+	Muscle   string  `json:"muscle"`
+	Sets     int     `json:"sets"`
+	Volume   float32 `json:"volume"`
+	Duration int     `json:"duration"`
+	Share    float32 `json:"share"`
+}
+
+type StatsStore struct {
+	db *sql.DB
+}
+
+func (s *StatsStore) Get(ctx context.Context, userID int64, since, until time.Time) (*Stats, error) {
+	st := &Stats{Since: since, Until: until}
+
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(duration), 0)
+		FROM finished_workouts
+		WHERE user_id = $1 AND ended_at >= $2 AND ended_at <= $3
+	`
+	err := s.db.QueryRowContext(ctx, query, userID, since, until).Scan(&st.Workouts, &st.TotalDuration)
+	if err != nil {
+		return nil, err
+	}
+	if weeks := until.Sub(since).Hours() / (24 * 7); weeks > 0 {
+		st.WorkoutsPerWeek = float64(st.Workouts) / weeks
+	}
+
+	days, err := s.getTrainingDays(ctx, userID, until)
+	if err != nil {
+		return nil, err
+	}
+	st.CurrentStreak, st.LongestStreak = Streaks(days, since, until)
+
+	if st.Muscles, err = s.getMuscleVolume(ctx, userID, since, until); err != nil {
+		return nil, err
+	}
+
+	if st.Weight, err = s.getWeight(ctx, userID, since, until); err != nil {
+		return nil, err
+	}
+	if n := len(st.Weight); n > 1 {
+		st.WeightChange = st.Weight[n-1].Weight - st.Weight[0].Weight
+	}
+
+	return st, nil
+}
+
+// getTrainingDays returns the days with at least one finished workout up to
+// until, newest first.
+func (s *StatsStore) getTrainingDays(ctx context.Context, userID int64, until time.Time) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT (ended_at AT TIME ZONE 'UTC')::date AS day
+		FROM finished_workouts
+		WHERE user_id = $1 AND ended_at <= $2
+		ORDER BY day DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]time.Time, 0)
+	for rows.Next() {
+		var d time.Time
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+func (s *StatsStore) getMuscleVolume(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]MuscleVolume, error) {
+	query := `
+		SELECT m.muscle, COUNT(*), COALESCE(SUM(fs.weight * fs.reps), 0), COALESCE(SUM(fs.duration), 0)
+		FROM finished_workout_sets fs
+		JOIN finished_workouts fw ON fw.id = fs.finished_workout_id
+		JOIN exercises e ON e.id = fs.exercise_id
+		CROSS JOIN LATERAL unnest(e.muscles) AS m(muscle)
+		WHERE fw.user_id = $1 AND fw.ended_at >= $2 AND fw.ended_at <= $3
+		GROUP BY m.muscle
+		ORDER BY COUNT(*) DESC, m.muscle
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var total int
+	muscles := make([]MuscleVolume, 0)
+	for rows.Next() {
+		var mv MuscleVolume
+		if err := rows.Scan(&mv.Muscle, &mv.Sets, &mv.Volume, &mv.Duration); err != nil {
+			return nil, err
+		}
+		total += mv.Sets
+		muscles = append(muscles, mv)
+	}
+	for i := range muscles {
+		muscles[i].Share = float32(muscles[i].Sets) / float32(total)
+	}
+
+	return muscles, rows.Err()
+}
+
+func (s *StatsStore) getWeight(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]UserWeightByDate, error) {
+	query := `
+		SELECT date, weight FROM user_weight
+		WHERE user_id = $1 AND date >= $2::date AND date <= $3::date
+		ORDER BY date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weight := make([]UserWeightByDate, 0)
+	for rows.Next() {
+		var uw UserWeightByDate
+		if err := rows.Scan(&uw.Date, &uw.Weight); err != nil {
+			return nil, err
+		}
+		weight = append(weight, uw)
+	}
+
+	return weight, rows.Err()
+}
+
+// Streaks computes the streak still running at until, which may have been
+// started before since, and the longest streak inside the window. days must
+// be sorted newest first.
+func Streaks(days []time.Time, since, until time.Time) (current, longest int) {
+	last := until.Truncate(24 * time.Hour)
+
+	run := 0
+	var prev time.Time
+	for i, d := range days {
+		if i > 0 && prev.Sub(d) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		// the current streak may end yesterday when the user did not train yet today
+		if run == i+1 && last.Sub(days[0]) <= 24*time.Hour {
+			current = run
+		}
+		if !d.Before(since.Truncate(24*time.Hour)) && run > longest {
+			longest = run
+		}
+		prev = d
+	}
+
+	return current, longest
+}
@@ -14,45 +14,141 @@ var (
 	ErrNotFound       = errors.New("entity not found")
 )
 
+// Storage groups the repositories, it is implemented on top of Postgres by New,
+// on top of SQLite by the sqlitestore package and in memory by the memstore
+// package.
 type Storage struct {
-	Users interface {
-		Create(context.Context, *sql.Tx, *User) error
-		GetByEmail(context.Context, string) (*User, error)
-		GetByID(context.Context, int64) (*User, error)
-		CreateAndInvite(context.Context, *User, string, time.Duration) error
-		Activate(context.Context, string) error
-		AddUserWeight(context.Context, int64, float32) error
-		GetUserAttr(context.Context, int64) (*UserAttributes, error)
-		UpdateUserWeight(context.Context, int64, float32) error
-		GetUserWeight(context.Context, pagination.PaginatedQuery, int64) ([]UserWeightByDate, error)
-	}
-	Exercises interface {
-		Create(context.Context, *Exercise) error
-		GetByID(context.Context, int64) (*Exercise, error)
-		GetAll(context.Context, pagination.PaginatedQuery) ([]Exercise, error)
-		GetUsersExercises(context.Context, pagination.PaginatedQuery, int64) ([]Exercise, error)
-	}
-	Workouts interface {
-		Create(context.Context, *Workout) error
-		GetAll(context.Context, pagination.PaginatedQuery, int64) ([]Workout, int, error)
-		GetByID(context.Context, int64) (*Workout, error)
-		GetUsersWorkouts(context.Context, pagination.PaginatedQuery, int64) ([]Workout, error)
-		GetWorkoutExercises(context.Context, int64) ([]WorkoutExercises, error)
-	}
-	Likes interface {
-		CreateExercise(context.Context, int64, int64) error
-		DeleteExercise(context.Context, int64, int64) error
-		CreateWorkout(context.Context, int64, int64) error
-		DeleteWorkout(context.Context, int64, int64) error
-	}
-	Reviews interface {
-		CreateWorkout(context.Context, *WorkoutReview) error
-		Get(context.Context, int64) ([]WorkoutReviewWithMetadata, error)
-	}
-	FinishedWorkouts interface {
-		Create(context.Context, *FinishedWorkout) error
-		GetAll(context.Context, int64) ([]FinishedWorkout, error)
-	}
+	Users            UsersRepo
+	Exercises        ExercisesRepo
+	Workouts         WorkoutsRepo
+	Likes            LikesRepo
+	Reviews          ReviewsRepo
+	FinishedWorkouts FinishedWorkoutsRepo
+	ActiveWorkouts   ActiveWorkoutsRepo
+	PersonalRecords  PersonalRecordsRepo
+	Measurements     MeasurementsRepo
+	Foods            FoodsRepo
+	Meals            MealsRepo
+	Stats            StatsRepo
+	Sessions         SessionsRepo
+}
+
+type UsersRepo interface {
+	GetByEmail(context.Context, string) (*User, error)
+	GetByID(context.Context, int64) (*User, error)
+	CreateAndInvite(context.Context, *User, string, time.Duration) error
+	Activate(context.Context, string) error
+	Reinvite(context.Context, int64, string, time.Duration) error
+	GetAll(context.Context, pagination.PaginatedQuery) ([]User, error)
+	Deactivate(context.Context, int64) error
+	SetRole(context.Context, int64, string) error
+	SetUnits(context.Context, int64, string) error
+	CreatePasswordReset(context.Context, int64, string, time.Duration) error
+	ResetPassword(context.Context, string, []byte) error
+	UpdateProfile(context.Context, int64, ProfileUpdate) error
+	ConfirmEmailChange(context.Context, string) error
+	AddUserWeight(context.Context, int64, float32) error
+	GetUserAttr(context.Context, int64) (*UserAttributes, error)
+	GetUserAttrAt(context.Context, int64, time.Time) (*UserAttributes, error)
+	UpdateUserAttr(context.Context, *UserAttributes) error
+	UpdateUserWeight(context.Context, int64, float32) error
+	GetUserWeight(context.Context, pagination.PaginatedQuery, int64) ([]UserWeightByDate, error)
+	GetUserWeightRange(context.Context, int64, time.Time, time.Time) ([]UserWeightByDate, error)
+}
+
+type ExercisesRepo interface {
+	Create(context.Context, *Exercise) error
+	GetByID(context.Context, int64) (*Exercise, error)
+	GetAll(context.Context, pagination.PaginatedQuery) ([]Exercise, error)
+	GetUsersExercises(context.Context, pagination.PaginatedQuery, int64) ([]Exercise, error)
+	Update(context.Context, *Exercise) error
+	Delete(context.Context, int64) error
+	GetDependentWorkouts(context.Context, int64) ([]Workout, error)
+	SetHidden(context.Context, int64, bool) error
+}
+
+type WorkoutsRepo interface {
+	Create(context.Context, *Workout) error
+	GetAll(context.Context, pagination.PaginatedQuery, int64) ([]Workout, int, error)
+	GetByID(context.Context, int64) (*Workout, error)
+	GetUsersWorkouts(context.Context, pagination.PaginatedQuery, int64) ([]Workout, error)
+	GetWorkoutExercises(context.Context, int64) ([]WorkoutExercises, error)
+	Update(context.Context, *Workout) error
+	Delete(context.Context, int64) error
+	SetHidden(context.Context, int64, bool) error
+}
+
+type LikesRepo interface {
+	CreateExercise(context.Context, int64, int64) error
+	DeleteExercise(context.Context, int64, int64) error
+	CreateWorkout(context.Context, int64, int64) error
+	DeleteWorkout(context.Context, int64, int64) error
+}
+
+type ReviewsRepo interface {
+	CreateWorkout(context.Context, *WorkoutReview) error
+	Get(context.Context, int64) ([]WorkoutReviewWithMetadata, error)
+	Update(context.Context, *WorkoutReview) error
+	Delete(context.Context, int64, int64) error
+	SetHidden(context.Context, int64, int64, bool) error
+}
+
+type FinishedWorkoutsRepo interface {
+	Create(context.Context, *FinishedWorkout) error
+	GetAll(context.Context, pagination.PaginatedQuery, int64) ([]FinishedWorkout, int, error)
+	GetByID(context.Context, int64) (*FinishedWorkout, error)
+}
+
+type ActiveWorkoutsRepo interface {
+	Start(context.Context, *ActiveWorkout) error
+	GetByUserID(context.Context, int64) (*ActiveWorkout, error)
+	Pause(context.Context, int64) error
+	Resume(context.Context, int64) error
+	AddSet(context.Context, int64, *WorkoutSet) error
+	Finish(context.Context, *ActiveWorkout, time.Time) (*FinishedWorkout, error)
+	Delete(context.Context, int64) error
+	DeleteStale(context.Context, time.Time) (int64, error)
+}
+
+type PersonalRecordsRepo interface {
+	GetByUserID(context.Context, int64) ([]PersonalRecord, error)
+	GetProgress(context.Context, pagination.PaginatedQuery, int64, int64) ([]ExerciseProgress, error)
+}
+
+type MeasurementsRepo interface {
+	Add(context.Context, *Measurement) error
+	Update(context.Context, *Measurement) error
+	GetRange(context.Context, int64, string, time.Time, time.Time) ([]Measurement, error)
+	GetLatest(context.Context, int64) ([]Measurement, error)
+	GetLatestAt(context.Context, int64, string, time.Time) (*Measurement, error)
+}
+
+type FoodsRepo interface {
+	Create(context.Context, *Food) error
+	GetByID(context.Context, int64) (*Food, error)
+	GetByBarcode(context.Context, string) (*Food, error)
+	GetAll(context.Context, pagination.PaginatedQuery) ([]Food, error)
+	Search(context.Context, pagination.PaginatedQuery) ([]Food, error)
+	Upsert(context.Context, []Food) error
+}
+
+type MealsRepo interface {
+	Create(context.Context, *Meal) error
+	GetByDate(context.Context, int64, time.Time) ([]Meal, error)
+	GetDailyCalories(context.Context, int64, time.Time, time.Time) ([]DailyIntake, error)
+	Delete(context.Context, int64, int64) error
+}
+
+type StatsRepo interface {
+	Get(context.Context, int64, time.Time, time.Time) (*Stats, error)
+}
+
+type SessionsRepo interface {
+	Create(context.Context, *Session) error
+	GetByID(context.Context, string) (*Session, error)
+	Rotate(context.Context, string, string, time.Time) (*Session, error)
+	Revoke(context.Context, string) error
+	RevokeAll(context.Context, int64) error
 }
 
 func New(db *sql.DB) Storage {
@@ -63,6 +159,13 @@ func New(db *sql.DB) Storage {
 		Workouts:         &WorkoutStore{db},
 		Reviews:          &ReviewsStore{db},
 		FinishedWorkouts: &FinishedWorkoutsStore{db},
+		ActiveWorkouts:   &ActiveWorkoutsStore{db},
+		PersonalRecords:  &PersonalRecordsStore{db},
+		Measurements:     &MeasurementsStore{db},
+		Foods:            &FoodsStore{db},
+		Meals:            &MealsStore{db},
+		Stats:            &StatsStore{db},
+		Sessions:         &SessionsStore{db},
 	}
 }
 
@@ -79,3 +182,27 @@ func withTx(db *sql.DB, ctx context.Context, fn func(*sql.Tx) error) error {
 
 	return tx.Commit()
 }
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execAffectingOne executes the statement and returns ErrNotFound when it
+// did not affect any row.
+func execAffectingOne(ctx context.Context, db execer, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -4,20 +4,97 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/lib/pq"
 
 	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
 )
 
+const (
+	ActivitySedentary  = "sedentary"
+	ActivityLight      = "light"
+	ActivityModerate   = "moderate"
+	ActivityActive     = "active"
+	ActivityVeryActive = "very_active"
+
+	FormulaMifflin        = "mifflin"
+	FormulaHarrisBenedict = "harris_benedict"
+	FormulaKatchMcArdle   = "katch_mcardle"
+)
+
 type UserAttributes struct {
-	UserID     int64   `json:"user_id"`
-	IsMale     bool    `json:"is_male"`
-	Height     int     `json:"height"`
-	Goal       string  `json:"goal"        validate:"required,oneof=lose gain maintain"`
-	WeightGoal float32 `json:"weight_goal"`
-	Weight     float32 `json:"weight"`
-	Age        int64   `json:"age"`
+	UserID     int64      `json:"user_id"`
+	IsMale     bool       `json:"is_male"`
+	Height     int        `json:"height"`
+	Goal       string     `json:"goal"           validate:"required,oneof=lose gain maintain"`
+	WeightGoal float32    `json:"weight_goal"`
+	Weight     float32    `json:"weight"`
+	BirthDate  *time.Time `json:"birth_date"`
+	// Age is computed from BirthDate, it is 0 when the birth date is unknown
+	Age           int64        `json:"age"`
+	ActivityLevel string       `json:"activity_level"`
+	Formula       string       `json:"formula"`
+	BodyFat       *float32     `json:"body_fat"`
+	MacroRatios   *MacroRatios `json:"macro_ratios"`
+}
+
+// MacroRatios splits the daily calories between the macronutrients, the
+// ratios sum up to 1.
+type MacroRatios struct {
+	Proteins      float32 `json:"proteins"      validate:"gte=0,lte=1"`
+	Fats          float32 `json:"fats"          validate:"gte=0,lte=1"`
+	Carbohydrates float32 `json:"carbohydrates" validate:"gte=0,lte=1"`
+}
+
+// AgeAt returns the age in full years on the date.
+func AgeAt(birthDate *time.Time, date time.Time) int64 {
+	if birthDate == nil {
+		return 0
+	}
+
+	age := date.Year() - birthDate.Year()
+	if date.Month() < birthDate.Month() ||
+		(date.Month() == birthDate.Month() && date.Day() < birthDate.Day()) {
+		age--
+	}
+	return int64(max(age, 0))
+}
+
+// userAttrColumns are the user_attributes columns read by scanUserAttr,
+// without the weight.
+const userAttrColumns = `ua.user_id, ua.is_male, ua.height, ua.goal, ua.weight_goal, ua.birth_date,
+	ua.activity_level, ua.formula, ua.body_fat, ua.protein_ratio, ua.fat_ratio, ua.carb_ratio`
+
+func scanUserAttr(row interface{ Scan(...any) error }, ua *UserAttributes, dest ...any) error {
+	var proteins, fats, carbs sql.NullFloat64
+	err := row.Scan(append([]any{
+		&ua.UserID,
+		&ua.IsMale,
+		&ua.Height,
+		&ua.Goal,
+		&ua.WeightGoal,
+		&ua.BirthDate,
+		&ua.ActivityLevel,
+		&ua.Formula,
+		&ua.BodyFat,
+		&proteins,
+		&fats,
+		&carbs,
+	}, dest...)...)
+	if err != nil {
+		return err
+	}
+
+	ua.Age = AgeAt(ua.BirthDate, time.Now())
+	if proteins.Valid && fats.Valid && carbs.Valid {
+		ua.MacroRatios = &MacroRatios{
+			Proteins:      float32(proteins.Float64),
+			Fats:          float32(fats.Float64),
+			Carbohydrates: float32(carbs.Float64),
+		}
+	}
+	return nil
 }
 
 type UserWeightByDate struct {
@@ -67,7 +144,7 @@ func (s *UserStore) UpdateUserWeight(
 // this return WITH last logged weight
 func (s *UserStore) GetUserAttr(ctx context.Context, userID int64) (*UserAttributes, error) {
 	query := `
-	SELECT ua.user_id, ua.is_male, ua.height, ua.goal, ua.weight_goal, uw.weight, ua.age 
+	SELECT ` + userAttrColumns + `, uw.weight
 	FROM user_attributes ua 
 	JOIN user_weight uw ON ua.user_id = uw.user_id 
 	WHERE ua.user_id = $1 AND uw.user_id = $1 
@@ -75,20 +152,81 @@ func (s *UserStore) GetUserAttr(ctx context.Context, userID int64) (*UserAttribu
 	LIMIT 1
 	`
 	userAttr := &UserAttributes{}
-	err := s.db.QueryRowContext(ctx, query, userID).Scan(&userAttr.UserID,
-		&userAttr.IsMale,
-		&userAttr.Height,
-		&userAttr.Goal,
-		&userAttr.WeightGoal,
-		&userAttr.Weight,
-		&userAttr.Age)
+	err := scanUserAttr(s.db.QueryRowContext(ctx, query, userID), userAttr, &userAttr.Weight)
+	if err != nil {
+		return nil, err
+	}
+
+	return userAttr, nil
+}
+
+// GetUserAttrAt returns the attributes with the weight that was current on
+// the date, falling back to the first logged weight for earlier dates.
+func (s *UserStore) GetUserAttrAt(
+	ctx context.Context,
+	userID int64,
+	date time.Time,
+) (*UserAttributes, error) {
+	query := `
+	SELECT ` + userAttrColumns + `, uw.weight
+	FROM user_attributes ua
+	JOIN user_weight uw ON ua.user_id = uw.user_id
+	WHERE ua.user_id = $1
+	ORDER BY uw.date <= $2::date DESC,
+	  CASE WHEN uw.date <= $2::date THEN uw.date END DESC,
+	  uw.date
+	LIMIT 1
+	`
+	userAttr := &UserAttributes{}
+	err := scanUserAttr(s.db.QueryRowContext(ctx, query, userID, date), userAttr, &userAttr.Weight)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
+	userAttr.Age = AgeAt(userAttr.BirthDate, date)
 
 	return userAttr, nil
 }
 
+// UpdateUserAttr saves the attributes except the weight, which is logged
+// separately.
+func (s *UserStore) UpdateUserAttr(ctx context.Context, ua *UserAttributes) error {
+	return updateUserAttr(ctx, s.db, ua)
+}
+
+func updateUserAttr(ctx context.Context, db execer, ua *UserAttributes) error {
+	query := `
+		UPDATE user_attributes
+		SET is_male = $2, height = $3, goal = $4, weight_goal = $5, birth_date = $6, activity_level = $7,
+		  formula = $8, body_fat = $9, protein_ratio = $10, fat_ratio = $11, carb_ratio = $12
+		WHERE user_id = $1
+	`
+	var proteins, fats, carbs *float32
+	if ua.MacroRatios != nil {
+		proteins, fats, carbs = &ua.MacroRatios.Proteins, &ua.MacroRatios.Fats, &ua.MacroRatios.Carbohydrates
+	}
+
+	return execAffectingOne(
+		ctx,
+		db,
+		query,
+		ua.UserID,
+		ua.IsMale,
+		ua.Height,
+		ua.Goal,
+		ua.WeightGoal,
+		ua.BirthDate,
+		ua.ActivityLevel,
+		ua.Formula,
+		ua.BodyFat,
+		proteins,
+		fats,
+		carbs,
+	)
+}
+
 func (s *UserStore) GetUserWeight(
 	ctx context.Context,
 	fq pagination.PaginatedQuery,
@@ -117,6 +255,36 @@ func (s *UserStore) GetUserWeight(
 	return userWeight, nil
 }
 
+// GetUserWeightRange returns the weight logged from since until the day
+// before until, oldest first.
+func (s *UserStore) GetUserWeightRange(
+	ctx context.Context,
+	userID int64,
+	since, until time.Time,
+) ([]UserWeightByDate, error) {
+	query := `
+		SELECT date, weight FROM user_weight
+		WHERE user_id = $1 AND date >= $2::date AND date < $3::date
+		ORDER BY date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weight := make([]UserWeightByDate, 0)
+	for rows.Next() {
+		var uw UserWeightByDate
+		if err := rows.Scan(&uw.Date, &uw.Weight); err != nil {
+			return nil, err
+		}
+		weight = append(weight, uw)
+	}
+
+	return weight, rows.Err()
+}
+
 func (s *UserStore) getLastWeight(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -145,17 +313,11 @@ func (s *UserStore) getUserAttr(
 	userID int64,
 ) (UserAttributes, error) {
 	query := `
-		SELECT user_id, is_male, height, goal, weight_goal, age FROM user_attributes WHERE user_id = $1
+		SELECT ` + userAttrColumns + ` FROM user_attributes ua WHERE ua.user_id = $1
 	`
 	var userAttr UserAttributes
 
-	err := tx.QueryRowContext(ctx, query, userID).Scan(
-		&userAttr.UserID,
-		&userAttr.IsMale,
-		&userAttr.Height,
-		&userAttr.Goal,
-		&userAttr.WeightGoal,
-		&userAttr.Age)
+	err := scanUserAttr(tx.QueryRowContext(ctx, query, userID), &userAttr)
 	if err != nil {
 		return userAttr, err
 	}
@@ -207,7 +369,8 @@ func (s *UserStore) addUserAttr(
 	userAttr UserAttributes,
 ) error {
 	query := `
-    INSERT INTO user_attributes (user_id, is_male, height, goal, weight_goal, age) VALUES ($1, $2,$3,$4,$5,$6)
+    INSERT INTO user_attributes (user_id, is_male, height, goal, weight_goal, birth_date, activity_level, formula)
+    VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'moderate'), COALESCE(NULLIF($8, ''), 'mifflin'))
   `
 
 	_, err := tx.ExecContext(
@@ -218,7 +381,9 @@ func (s *UserStore) addUserAttr(
 		userAttr.Height,
 		userAttr.Goal,
 		userAttr.WeightGoal,
-		userAttr.Age,
+		userAttr.BirthDate,
+		userAttr.ActivityLevel,
+		userAttr.Formula,
 	)
 	if err != nil {
 		return err
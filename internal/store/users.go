@@ -8,7 +8,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stanislavCasciuc/atom-fit/internal/lib/mailer/pagination"
 )
 
 var (
@@ -16,19 +19,48 @@ var (
 	ErrDuplicateUsername = errors.New("a user with that username already exists")
 )
 
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
+)
+
 type UserStore struct {
 	db *sql.DB
 }
 
 type User struct {
-	ID        int64          `json:"id"`
-	Email     string         `json:"email"`
-	Username  string         `json:"username"`
-	Password  password       `json:"-"`
-	CreatedAt string         `json:"created_at"`
-	IsActive  bool           `json:"is_active"`
-	UserAttr  UserAttributes `json:"user_attr"`
+	ID                int64          `json:"id"`
+	Email             string         `json:"email"`
+	Username          string         `json:"username"`
+	Password          password       `json:"-"`
+	CreatedAt         string         `json:"created_at"`
+	IsActive          bool           `json:"is_active"`
+	Role              string         `json:"role"`
+	Units             string         `json:"units"`
+	PasswordChangedAt sql.NullTime   `json:"-"`
+	UserAttr          UserAttributes `json:"user_attr"`
+}
+
+// ProfileUpdate holds the changes of a user profile, nil fields are left
+// as they are. A new PasswordHash revokes the sessions of the user and
+// EmailChange replaces the pending email change.
+type ProfileUpdate struct {
+	Username     *string
+	Units        *string
+	UserAttr     *UserAttributes
+	PasswordHash []byte
+	EmailChange  *EmailChange
 }
+
+// EmailChange is a new email waiting for the confirmation of the hashed
+// Token, which expires after Exp.
+type EmailChange struct {
+	Email string
+	Token string
+	Exp   time.Duration
+}
+
 type password struct {
 	Text *string
 	Hash []byte
@@ -48,15 +80,15 @@ func (p *password) Set(text string) error {
 
 func (s *UserStore) Create(ctx context.Context, tx *sql.Tx, u *User) error {
 	query := `
-		INSERT INTO users (email, username, password)
-		VALUES ($1, $2, $3)
-		RETURNING id, created_at
+		INSERT INTO users (email, username, password, units)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'metric'))
+		RETURNING id, created_at, units
 		`
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeDuration)
 	defer cancel()
 
-	err := tx.QueryRowContext(ctx, query, u.Email, u.Username, u.Password.Hash).
-		Scan(&u.ID, &u.CreatedAt)
+	err := tx.QueryRowContext(ctx, query, u.Email, u.Username, u.Password.Hash, u.Units).
+		Scan(&u.ID, &u.CreatedAt, &u.Units)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
@@ -102,13 +134,13 @@ func (s *UserStore) CreateAndInvite(
 
 func (s *UserStore) GetByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, username, password, created_at, is_active FROM users WHERE email = $1
+		SELECT id, email, username, password, created_at, is_active, role, password_changed_at, units FROM users WHERE email = $1
 	`
 
 	var pass []byte
 	u := &User{}
 	err := s.db.QueryRowContext(ctx, query, email).
-		Scan(&u.ID, &u.Email, &u.Username, &pass, &u.CreatedAt, &u.IsActive)
+		Scan(&u.ID, &u.Email, &u.Username, &pass, &u.CreatedAt, &u.IsActive, &u.Role, &u.PasswordChangedAt, &u.Units)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -121,13 +153,13 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*User, error)
 
 func (s *UserStore) GetByID(ctx context.Context, id int64) (*User, error) {
 	query := `
-		SELECT id, email, username, password, created_at, is_active FROM users WHERE id = $1
+		SELECT id, email, username, password, created_at, is_active, role, password_changed_at, units FROM users WHERE id = $1
 	`
 
 	var pass []byte
 	u := &User{}
 	err := s.db.QueryRowContext(ctx, query, id).
-		Scan(&u.ID, &u.Email, &u.Username, &pass, &u.CreatedAt, &u.IsActive)
+		Scan(&u.ID, &u.Email, &u.Username, &pass, &u.CreatedAt, &u.IsActive, &u.Role, &u.PasswordChangedAt, &u.Units)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -138,6 +170,66 @@ func (s *UserStore) GetByID(ctx context.Context, id int64) (*User, error) {
 	return u, nil
 }
 
+func (s *UserStore) GetAll(ctx context.Context, fq pagination.PaginatedQuery) ([]User, error) {
+	query := `
+		SELECT id, email, username, created_at, is_active, role, units FROM users
+		WHERE username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%'
+		ORDER BY id ` + fq.Sort + `
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, fq.Search, fq.Limit, fq.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		var u User
+		err := rows.Scan(&u.ID, &u.Email, &u.Username, &u.CreatedAt, &u.IsActive, &u.Role, &u.Units)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// Deactivate marks the user as inactive and revokes all of its sessions.
+func (s *UserStore) Deactivate(ctx context.Context, userID int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE users SET is_active = FALSE WHERE id = $1`, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrNotFound
+		}
+
+		return revokeUserSessions(ctx, tx, userID)
+	})
+}
+
+func (s *UserStore) SetRole(ctx context.Context, userID int64, role string) error {
+	query := `
+		UPDATE users SET role = $1 WHERE id = $2
+	`
+	return execAffectingOne(ctx, s.db, query, role, userID)
+}
+
+func (s *UserStore) SetUnits(ctx context.Context, userID int64, units string) error {
+	query := `
+		UPDATE users SET units = $1 WHERE id = $2
+	`
+	return execAffectingOne(ctx, s.db, query, units, userID)
+}
+
 func (s *UserStore) Activate(ctx context.Context, token string) error {
 	return withTx(s.db, ctx, func(tx *sql.Tx) error {
 		u, err := s.getFormInviteToken(ctx, tx, token)
@@ -158,6 +250,193 @@ func (s *UserStore) Activate(ctx context.Context, token string) error {
 	})
 }
 
+// Reinvite replaces the pending invitations of the user with a new one.
+func (s *UserStore) Reinvite(
+	ctx context.Context,
+	userID int64,
+	token string,
+	exp time.Duration,
+) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.deleteInvitation(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.createInvite(ctx, tx, userID, token, exp)
+	})
+}
+
+// CreatePasswordReset replaces the pending password resets of the user with a new one.
+func (s *UserStore) CreatePasswordReset(
+	ctx context.Context,
+	userID int64,
+	token string,
+	exp time.Duration,
+) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.deletePasswordResets(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO password_resets (token, user_id, exp)
+			VALUES ($1, $2, $3)
+		`
+		_, err := tx.ExecContext(ctx, query, token, userID, time.Now().Add(exp))
+		return err
+	})
+}
+
+// ResetPassword sets the new password hash for the user of the plain reset
+// token and revokes all sessions of the user. The token can be used only once.
+func (s *UserStore) ResetPassword(ctx context.Context, plainToken string, hash []byte) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			SELECT user_id FROM password_resets
+			WHERE token = $1 AND exp > $2
+		`
+
+		h := sha256.Sum256([]byte(plainToken))
+		hashToken := hex.EncodeToString(h[:])
+
+		var userID int64
+		err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if err := s.updatePassword(ctx, tx, userID, hash); err != nil {
+			return err
+		}
+
+		if err := revokeUserSessions(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return s.deletePasswordResets(ctx, tx, userID)
+	})
+}
+
+// UpdateProfile applies the profile changes of the user in one transaction,
+// the email is changed once the token sent to the new address is confirmed.
+func (s *UserStore) UpdateProfile(ctx context.Context, userID int64, p ProfileUpdate) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if p.Username != nil {
+			query := `UPDATE users SET username = $1 WHERE id = $2`
+			if err := execAffectingOne(ctx, tx, query, *p.Username, userID); err != nil {
+				var pqErr *pq.Error
+				if errors.As(err, &pqErr) && pqErr.Constraint == "users_username_key" {
+					return ErrDuplicateUsername
+				}
+				return err
+			}
+		}
+
+		if p.Units != nil {
+			query := `UPDATE users SET units = $1 WHERE id = $2`
+			if err := execAffectingOne(ctx, tx, query, *p.Units, userID); err != nil {
+				return err
+			}
+		}
+
+		if p.UserAttr != nil {
+			if err := updateUserAttr(ctx, tx, p.UserAttr); err != nil {
+				return err
+			}
+		}
+
+		if p.PasswordHash != nil {
+			if err := s.updatePassword(ctx, tx, userID, p.PasswordHash); err != nil {
+				return err
+			}
+			if err := revokeUserSessions(ctx, tx, userID); err != nil {
+				return err
+			}
+		}
+
+		if p.EmailChange != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM email_changes WHERE user_id = $1`, userID); err != nil {
+				return err
+			}
+
+			query := `
+				INSERT INTO email_changes (token, user_id, email, exp)
+				VALUES ($1, $2, $3, $4)
+			`
+			ec := p.EmailChange
+			if _, err := tx.ExecContext(ctx, query, ec.Token, userID, ec.Email, time.Now().Add(ec.Exp)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ConfirmEmailChange sets the pending email of the plain token as the user
+// email. The token can be used only once.
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, plainToken string) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM email_changes
+			WHERE token = $1 AND exp > $2
+			RETURNING user_id, email
+		`
+
+		h := sha256.Sum256([]byte(plainToken))
+		hashToken := hex.EncodeToString(h[:])
+
+		var userID int64
+		var email string
+		err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(&userID, &email)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE users SET email = $1 WHERE id = $2`, email, userID)
+		if err != nil {
+			if err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"` {
+				return ErrDuplicateEmail
+			}
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (s *UserStore) updatePassword(ctx context.Context, tx *sql.Tx, userID int64, hash []byte) error {
+	query := `
+		UPDATE users SET password = $1, password_changed_at = NOW() WHERE id = $2
+	`
+
+	_, err := tx.ExecContext(ctx, query, hash, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserStore) deletePasswordResets(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `
+		DELETE FROM password_resets WHERE user_id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *UserStore) createInvite(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -203,14 +482,14 @@ func (s *UserStore) getFormInviteToken(
 		SELECT u.id, u.email, u.username,  u.is_active, u.created_at
 		FROM users u
 		JOIN invitation i ON u.id = i.user_id 
-		WHERE i.token = $1
+		WHERE i.token = $1 AND i.exp > $2
 	`
 
 	hash := sha256.Sum256([]byte(plainToken))
 	hashToken := hex.EncodeToString(hash[:])
 
 	u := &User{}
-	err := tx.QueryRowContext(ctx, query, hashToken).Scan(
+	err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(
 		&u.ID,
 		&u.Email,
 		&u.Username,
@@ -22,12 +22,14 @@ type Workout struct {
 	Rating           float32            `json:"rating"`
 	ReviewsCount     int                `json:"reviews_count"`
 	UserLiked        bool               `json:"user_liked"`
+	IsHidden         bool               `json:"is_hidden"`
 }
 
 type WorkoutExercises struct {
 	WorkoutID  int64    `json:"workout_id"`
 	ExerciseID int64    `json:"exercise_id"`
 	Duration   int      `json:"duration"`
+	Position   int      `json:"position"`
 	Exercise   Exercise `json:"exercise"`
 }
 
@@ -35,6 +37,22 @@ type WorkoutStore struct {
 	db *sql.DB
 }
 
+// UniqueExercises returns the workout exercises without the repeated ones,
+// keeping the first occurrence, positioned in slice order.
+func UniqueExercises(wes []WorkoutExercises) []WorkoutExercises {
+	unique := make([]WorkoutExercises, 0, len(wes))
+	seen := make(map[int64]bool)
+	for _, we := range wes {
+		if seen[we.ExerciseID] {
+			continue
+		}
+		seen[we.ExerciseID] = true
+		we.Position = len(unique)
+		unique = append(unique, we)
+	}
+	return unique
+}
+
 func (s *WorkoutStore) GetAll(
 	ctx context.Context,
 	fq pagination.PaginatedQuery,
@@ -63,13 +81,14 @@ func (s *WorkoutStore) GetAll(
 	LEFT JOIN workout_exercises we ON we.workout_id = w.id 
 	LEFT JOIN exercises e ON we.exercise_id = e.id 
 	LEFT JOIN workout_likes wl ON w.id = wl.workout_id 
-	LEFT JOIN workout_reviews wr ON w.id = wr.workout_id 
+	LEFT JOIN workout_reviews wr ON w.id = wr.workout_id AND NOT wr.is_hidden 
 	WHERE 
 	  (e.name ILIKE '%' || $1 || '%' 
 	  OR e.description ILIKE '%' || $1 || '%' 
 	  OR w.name ILIKE '%' || $1 || '%' 
 	  OR w.description ILIKE '%' || $1 || '%') 
 	  AND (e.muscles @> $2 OR $2 = '{}') 
+	  AND NOT w.is_hidden
 	GROUP BY w.id 
 	ORDER BY likes ` + fq.Sort + ` 
 	LIMIT $3 OFFSET $4
@@ -142,9 +161,9 @@ func (s *WorkoutStore) GetUsersWorkouts(
 	LEFT JOIN workout_exercises we ON we.workout_id = w.id 
 	LEFT JOIN exercises e ON we.exercise_id = e.id 
 	LEFT JOIN workout_likes wl ON w.id = wl.workout_id 
-	LEFT JOIN workout_reviews wr ON w.id = wr.workout_id 
+	LEFT JOIN workout_reviews wr ON w.id = wr.workout_id AND NOT wr.is_hidden 
 	WHERE 
-	 w.user_id = $1 
+	 w.user_id = $1 AND NOT w.is_hidden
 	GROUP BY w.id 
 	ORDER BY likes ` + fq.Sort + ` 
 	LIMIT $2 OFFSET $3
@@ -192,28 +211,97 @@ func (s *WorkoutStore) Create(ctx context.Context, w *Workout) error {
 			return err
 		}
 
-		for _, e := range w.WorkoutExercises {
-			e.WorkoutID = w.ID
-			if err := s.addExerciseToWorkout(ctx, tx, &e); err != nil {
-				if errors.Is(err, ErrConflict) {
-					continue
-				}
+		return s.addExercisesToWorkout(ctx, tx, w)
+	})
+}
+
+// Update changes the workout details and, when WorkoutExercises is not nil,
+// replaces the workout exercises with the given ones in their slice order.
+func (s *WorkoutStore) Update(ctx context.Context, w *Workout) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		if err := s.updateWorkout(ctx, tx, w); err != nil {
+			return err
+		}
+
+		if w.WorkoutExercises == nil {
+			return nil
+		}
+
+		if err := s.deleteWorkoutExercises(ctx, tx, w.ID); err != nil {
+			return err
+		}
+
+		return s.addExercisesToWorkout(ctx, tx, w)
+	})
+}
+
+// Delete removes the workout together with its exercises, likes, reviews
+// and finished workouts.
+func (s *WorkoutStore) Delete(ctx context.Context, id int64) error {
+	return withTx(s.db, ctx, func(tx *sql.Tx) error {
+		stmts := []string{
+			`DELETE FROM workout_likes WHERE workout_id = $1`,
+			`DELETE FROM workout_reviews WHERE workout_id = $1`,
+			`DELETE FROM finished_workouts WHERE workout_id = $1`,
+			`DELETE FROM workout_exercises WHERE workout_id = $1`,
+		}
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
 				return err
 			}
 		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM workouts WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrNotFound
+		}
+
 		return nil
 	})
 }
 
 func (s *WorkoutStore) GetByID(ctx context.Context, id int64) (*Workout, error) {
 	query := `
-		SELECT id, user_id, name, description, tutorial_link, created_at FROM workouts 
-		WHERE id = $1
+		SELECT 
+		  w.id, 
+		  w.user_id, 
+		  w.name, 
+		  w.description, 
+		  w.tutorial_link, 
+		  w.created_at,
+		  (SELECT COUNT(*) FROM workout_likes wl WHERE wl.workout_id = w.id) AS likes,
+		  (SELECT COUNT(*) FROM workout_reviews wr WHERE wr.workout_id = w.id AND NOT wr.is_hidden) AS reviews_count,
+		  (SELECT COALESCE(AVG(wr.rating), 0.0) FROM workout_reviews wr WHERE wr.workout_id = w.id AND NOT wr.is_hidden) AS average_rating,
+		  w.is_hidden
+		FROM workouts w
+		WHERE w.id = $1
 	`
 	w := &Workout{}
 	err := s.db.QueryRowContext(ctx, query, id).
-		Scan(&w.ID, &w.UserID, &w.Name, &w.Description, &w.TutorialLink, &w.CreatedAt)
+		Scan(
+			&w.ID,
+			&w.UserID,
+			&w.Name,
+			&w.Description,
+			&w.TutorialLink,
+			&w.CreatedAt,
+			&w.Likes,
+			&w.ReviewsCount,
+			&w.Rating,
+			&w.IsHidden,
+		)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
@@ -225,9 +313,10 @@ func (s *WorkoutStore) GetWorkoutExercises(
 	workoutID int64,
 ) ([]WorkoutExercises, error) {
 	query := `
-		SELECT id, user_id, name, description, is_duration, e.duration, tutorial_link, created_at, muscles, we.duration FROM exercises e 
+		SELECT id, user_id, name, description, is_duration, e.duration, tutorial_link, created_at, muscles, we.duration, we.position FROM exercises e 
 		JOIN workout_exercises we ON e.id = we.exercise_id
 		WHERE we.workout_id = $1
+		ORDER BY we.position
 	`
 	workoutExercises := make([]WorkoutExercises, 0)
 	rows, err := s.db.QueryContext(ctx, query, workoutID)
@@ -236,7 +325,7 @@ func (s *WorkoutStore) GetWorkoutExercises(
 	}
 	for rows.Next() {
 		var e Exercise
-		var duration int
+		var duration, position int
 		err := rows.Scan(
 			&e.ID,
 			&e.UserID,
@@ -248,6 +337,7 @@ func (s *WorkoutStore) GetWorkoutExercises(
 			&e.CreatedAt,
 			pq.Array(&e.Muscles),
 			&duration,
+			&position,
 		)
 		if err != nil {
 			return nil, err
@@ -257,6 +347,7 @@ func (s *WorkoutStore) GetWorkoutExercises(
 			Exercise:   e,
 			WorkoutID:  workoutID,
 			Duration:   duration,
+			Position:   position,
 		}
 
 		workoutExercises = append(workoutExercises, we)
@@ -278,20 +369,72 @@ func (s *WorkoutStore) createWorkout(ctx context.Context, tx *sql.Tx, w *Workout
 	return nil
 }
 
+func (s *WorkoutStore) updateWorkout(ctx context.Context, tx *sql.Tx, w *Workout) error {
+	query := `
+    UPDATE workouts SET name = $1, description = $2, tutorial_link = $3 WHERE id = $4
+  `
+	res, err := tx.ExecContext(ctx, query, w.Name, w.Description, w.TutorialLink, w.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *WorkoutStore) deleteWorkoutExercises(ctx context.Context, tx *sql.Tx, workoutID int64) error {
+	query := `
+    DELETE FROM workout_exercises WHERE workout_id = $1
+  `
+	_, err := tx.ExecContext(ctx, query, workoutID)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// addExercisesToWorkout adds the workout exercises positioned in slice order.
+// Repeated exercises are dropped first, a unique violation would abort the
+// transaction.
+func (s *WorkoutStore) addExercisesToWorkout(ctx context.Context, tx *sql.Tx, w *Workout) error {
+	w.WorkoutExercises = UniqueExercises(w.WorkoutExercises)
+	for i := range w.WorkoutExercises {
+		w.WorkoutExercises[i].WorkoutID = w.ID
+		if err := s.addExerciseToWorkout(ctx, tx, &w.WorkoutExercises[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *WorkoutStore) addExerciseToWorkout(
 	ctx context.Context,
 	tx *sql.Tx,
 	we *WorkoutExercises,
 ) error {
 	query := `
-    INSERT INTO workout_exercises (exercise_id, workout_id, duration) VALUES($1, $2, $3)
+    INSERT INTO workout_exercises (exercise_id, workout_id, duration, position) VALUES($1, $2, $3, $4)
   `
-	_, err := tx.ExecContext(ctx, query, we.ExerciseID, we.WorkoutID, we.Duration)
+	_, err := tx.ExecContext(ctx, query, we.ExerciseID, we.WorkoutID, we.Duration, we.Position)
 	if err != nil {
-		if err.(*pq.Error).Code == "23505" {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
 			return ErrConflict
 		}
-		return err
+		return fkViolationAsNotFound(err)
 	}
 	return nil
 }
+
+func (s *WorkoutStore) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	query := `
+		UPDATE workouts SET is_hidden = $1 WHERE id = $2
+	`
+	return execAffectingOne(ctx, s.db, query, hidden, id)
+}